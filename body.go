@@ -0,0 +1,271 @@
+package zap4echo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const defaultBodyMaxSize = 4 << 10 // 4 KB
+
+// The value that replaces redacted data.
+const redacted = "[REDACTED]"
+
+// Content types whose bodies are logged by default.
+// A content type ending with `/*` matches all subtypes.
+var DefaultBodyContentTypes = []string{
+	"application/json",
+	"application/x-www-form-urlencoded",
+	"text/*",
+}
+
+// A writer that keeps the first `max` bytes written to it, and discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func newLimitedBuffer(max int) *limitedBuffer {
+	return &limitedBuffer{max: max}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if remaining := b.max - b.buf.Len(); remaining < n {
+		p = p[:remaining]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Capture the request body while the handler reads it.
+func captureRequestBody(body io.ReadCloser, max int) (io.ReadCloser, *limitedBuffer) {
+	buf := newLimitedBuffer(max)
+	return teeReadCloser{Reader: io.TeeReader(body, buf), Closer: body}, buf
+}
+
+// Captures the response body while it is written.
+type responseBodyWriter struct {
+	http.ResponseWriter
+	buf *limitedBuffer
+}
+
+func (w *responseBodyWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseBodyWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *responseBodyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *responseBodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	// Structured syntax suffixes, e.g. application/problem+json
+	if strings.HasSuffix(mt, "+json") {
+		return "application/json"
+	}
+	return mt
+}
+
+func matchContentType(contentType string, allowed []string) bool {
+	mt := mediaType(contentType)
+	if mt == "" {
+		return false
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if strings.HasSuffix(a, "/*") {
+			if strings.HasPrefix(mt, a[:len(a)-1]) {
+				return true
+			}
+		} else if mt == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact the values of given keys from a JSON or form body.
+//
+// A key without a dot matches at any depth. A key with dots
+// (e.g. `user.password`) matches the path from the root of the JSON document.
+func redactBody(body []byte, contentType string, keys []string) string {
+	if len(keys) == 0 || len(body) == 0 {
+		return string(body)
+	}
+
+	switch mediaType(contentType) {
+	case "application/json":
+		return redactJSON(body, keys)
+	case "application/x-www-form-urlencoded":
+		return redactRawQuery(string(body), keys)
+	default:
+		return string(body)
+	}
+}
+
+// Redact the values in place, so that the rest of the body is logged as is.
+func redactJSON(body []byte, keys []string) string {
+	r := jsonRedactor{body: body, keys: keys, d: json.NewDecoder(bytes.NewReader(body))}
+	r.d.UseNumber()
+	if err := r.value(nil); err != nil {
+		// Most likely truncated. Fall back to pattern matching.
+		return redactJSONPattern(body, keys)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, span := range r.spans {
+		b.Write(body[last:span[0]])
+		b.WriteString(`"` + redacted + `"`)
+		last = span[1]
+	}
+	b.Write(body[last:])
+	return b.String()
+}
+
+// Finds the byte ranges of the values to redact in a JSON document.
+type jsonRedactor struct {
+	body  []byte
+	keys  []string
+	d     *json.Decoder
+	spans [][2]int
+}
+
+// Read a value, whose path is given.
+func (r *jsonRedactor) value(path []string) error {
+	t, err := r.d.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		for r.d.More() {
+			t, err := r.d.Token()
+			if err != nil {
+				return err
+			}
+			p := append(path[:len(path):len(path)], t.(string))
+			if !matchKey(p, r.keys) {
+				if err := r.value(p); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// The value starts after the colon that follows the key.
+			start := int(r.d.InputOffset())
+			for start < len(r.body) && (r.body[start] == ':' || isJSONSpace(r.body[start])) {
+				start++
+			}
+			if err := r.skip(); err != nil {
+				return err
+			}
+			r.spans = append(r.spans, [2]int{start, int(r.d.InputOffset())})
+		}
+	case json.Delim('['):
+		for r.d.More() {
+			if err := r.value(path); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	// The closing delimiter.
+	_, err = r.d.Token()
+	return err
+}
+
+// Read a value without looking into it.
+func (r *jsonRedactor) skip() error {
+	depth := 0
+	for {
+		t, err := r.d.Token()
+		if err != nil {
+			return err
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func matchKey(path []string, keys []string) bool {
+	last := path[len(path)-1]
+	for _, key := range keys {
+		if strings.Contains(key, ".") {
+			if strings.EqualFold(key, strings.Join(path, ".")) {
+				return true
+			}
+		} else if strings.EqualFold(key, last) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact values of keys from a JSON document that cannot be decoded.
+// Paths cannot be resolved, so only the last part of dotted keys is used.
+func redactJSONPattern(body []byte, keys []string) string {
+	s := string(body)
+	for _, key := range keys {
+		if i := strings.LastIndex(key, "."); i >= 0 {
+			key = key[i+1:]
+		}
+		re := regexp.MustCompile(`(?i)("` + regexp.QuoteMeta(key) + `"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]*)`)
+		s = re.ReplaceAllString(s, `${1}"`+redacted+`"`)
+	}
+	return s
+}
+
+func redactValues(values url.Values, keys []string) {
+	for k, vs := range values {
+		for _, key := range keys {
+			if strings.EqualFold(k, key) {
+				for i := range vs {
+					vs[i] = redacted
+				}
+				break
+			}
+		}
+	}
+}
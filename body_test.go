@@ -0,0 +1,328 @@
+package zap4echo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerWithRequestBody(t *testing.T) {
+	config := LoggerConfig{
+		LogRequestBody: true,
+		RedactBodyKeys: []string{"password", "user.token"},
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	const reqBody = `{"name":"john","password":"hunter2","user":{"token":"abc","password":"x"},"token":"keep"}`
+
+	e.POST("/login", func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		assert.NoError(t, err)
+		// Handler must receive the body intact.
+		assert.Equal(t, reqBody, string(body))
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("POST", "/login", strings.NewReader(reqBody))
+	r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	l := logs.All()[0]
+	assert.Equal(t,
+		`{"name":"john","password":"[REDACTED]","user":{"token":"[REDACTED]","password":"[REDACTED]"},"token":"keep"}`,
+		l.ContextMap()["request_body"].(string))
+	assert.Nil(t, l.ContextMap()["request_body_truncated"])
+}
+
+func TestLoggerWithNegativeBodyMaxSize(t *testing.T) {
+	config := LoggerConfig{
+		LogRequestBody:      true,
+		RequestBodyMaxSize:  -1,
+		LogResponseBody:     true,
+		ResponseBodyMaxSize: -1,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.POST("/echo", func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, string(body))
+	})
+
+	r := httptest.NewRequest("POST", "/echo", strings.NewReader("Hello!"))
+	r.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	l := logs.All()[0]
+	assert.Equal(t, "Hello!", l.ContextMap()["request_body"].(string))
+	assert.Equal(t, "Hello!", l.ContextMap()["response_body"].(string))
+}
+
+func TestLoggerWithUnreadRequestBody(t *testing.T) {
+	config := LoggerConfig{
+		LogRequestBody:       true,
+		RequestBodyErrorOnly: true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.POST("/login", func(c echo.Context) error {
+		return c.NoContent(http.StatusUnauthorized)
+	})
+
+	r := httptest.NewRequest("POST", "/login", strings.NewReader(`{"name":"john"}`))
+	r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, int64(http.StatusUnauthorized), l.ContextMap()["status"].(int64))
+	assert.NotContains(t, l.ContextMap(), "request_body")
+}
+
+func TestLoggerWithRequestBodyMaxSize(t *testing.T) {
+	config := LoggerConfig{
+		LogRequestBody:     true,
+		RequestBodyMaxSize: 5,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.POST("/", func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		return c.String(http.StatusOK, string(body))
+	})
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("Hello, World!"))
+	r.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Hello, World!", w.Body.String())
+
+	l := logs.All()[0]
+	assert.Equal(t, "Hello", l.ContextMap()["request_body"].(string))
+	assert.Equal(t, true, l.ContextMap()["request_body_truncated"].(bool))
+}
+
+func TestLoggerWithRequestBodyFilters(t *testing.T) {
+	config := LoggerConfig{
+		LogRequestBody:       true,
+		RequestBodyErrorOnly: true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.POST("/:status", func(c echo.Context) error {
+		io.ReadAll(c.Request().Body)
+		if c.Param("status") == "400" {
+			return c.NoContent(http.StatusBadRequest)
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Not an error
+	r := httptest.NewRequest("POST", "/200", strings.NewReader("a=b"))
+	r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	assert.Nil(t, logs.All()[0].ContextMap()["request_body"])
+
+	// Content type is not allowed
+	r = httptest.NewRequest("POST", "/400", strings.NewReader("binary"))
+	r.Header.Set(echo.HeaderContentType, echo.MIMEOctetStream)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	assert.Nil(t, logs.All()[1].ContextMap()["request_body"])
+
+	r = httptest.NewRequest("POST", "/400", strings.NewReader("a=b"))
+	r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	assert.Equal(t, "a=b", logs.All()[2].ContextMap()["request_body"].(string))
+}
+
+func TestRedactBody(t *testing.T) {
+	keys := []string{"password", "token"}
+
+	// The order of the keys, escaping and formatting are kept.
+	assert.Equal(t,
+		"user=john+doe&password=[REDACTED]&z=%3C%3E",
+		redactBody([]byte("user=john+doe&password=hunter2&z=%3C%3E"), echo.MIMEApplicationForm, keys))
+
+	assert.Equal(t,
+		`{"z":1,"a":"<b>&", "password" : "[REDACTED]","user":{"token":"[REDACTED]","tags":["x"]}}`,
+		redactBody([]byte(`{"z":1,"a":"<b>&", "password" : {"x":[1,2]},"user":{"token":"ab","tags":["x"]}}`), echo.MIMEApplicationJSON, keys))
+
+	assert.Equal(t,
+		`[{"id":1.50,"password":"[REDACTED]"},{"id":2}]`,
+		redactBody([]byte(`[{"id":1.50,"password":"x"},{"id":2}]`), echo.MIMEApplicationJSON, keys))
+
+	assert.Equal(t,
+		`{"user":{"password":"[REDACTED]"},"password":"p"}`,
+		redactBody([]byte(`{"user":{"password":"p"},"password":"p"}`), echo.MIMEApplicationJSON, []string{"user.password"}))
+
+	// Truncated JSON cannot be decoded.
+	assert.Equal(t,
+		`{"user":"john","password": "[REDACTED]","token":"[REDACTED]"`,
+		redactBody([]byte(`{"user":"john","password": "hunter2","token":"ab`), echo.MIMEApplicationJSON, keys))
+
+	assert.Equal(t,
+		`{"password":"[REDACTED]"}`,
+		redactBody([]byte(`{"password":1234}`), "application/problem+json", keys))
+
+	assert.Equal(t, "password=hunter2", redactBody([]byte("password=hunter2"), echo.MIMETextPlain, keys))
+}
+
+func TestLoggerWithResponseBody(t *testing.T) {
+	config := LoggerConfig{
+		LogResponseBody:       true,
+		ResponseBodyErrorOnly: true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/success", func(c echo.Context) error {
+		return c.String(http.StatusOK, "Hello!")
+	})
+
+	e.GET("/error", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "confusing error message")
+	})
+
+	r := httptest.NewRequest("GET", "/success", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Hello!", w.Body.String())
+	assert.Nil(t, logs.All()[0].ContextMap()["response_body"])
+
+	r = httptest.NewRequest("GET", "/error", nil)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res = w.Result()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	l := logs.All()[1]
+	assert.Contains(t, l.ContextMap()["response_body"].(string), "confusing error message")
+	assert.Equal(t, w.Body.String(), l.ContextMap()["response_body"].(string))
+}
+
+func TestLoggerWithResponseBodyMaxSize(t *testing.T) {
+	config := LoggerConfig{
+		LogResponseBody:     true,
+		ResponseBodyMaxSize: 5,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "Hello, World!")
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Hello, World!", w.Body.String())
+
+	l := logs.All()[0]
+	assert.Equal(t, "Hello", l.ContextMap()["response_body"].(string))
+	assert.Equal(t, true, l.ContextMap()["response_body_truncated"].(bool))
+}
+
+func TestLoggerWithResponseBodyFlush(t *testing.T) {
+	config := LoggerConfig{
+		LogResponseBody: true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextPlain)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("Hello"))
+		c.Response().Flush()
+
+		// The writer of the response is still reachable.
+		unwrapper, ok := c.Response().Writer.(interface{ Unwrap() http.ResponseWriter })
+		assert.True(t, ok)
+		_, ok = unwrapper.Unwrap().(*httptest.ResponseRecorder)
+		assert.True(t, ok)
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "Hello", logs.All()[0].ContextMap()["response_body"].(string))
+}
+
+func TestLoggerWithResponseBodyHijack(t *testing.T) {
+	config := LoggerConfig{
+		LogResponseBody: true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/", func(c echo.Context) error {
+		conn, _, err := c.Response().Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Write([]byte("HTTP/1.1 204 No Content\r\n\r\n"))
+		return err
+	})
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	assert.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Nil(t, logs.All()[0].ContextMap()["error"])
+}
@@ -0,0 +1,72 @@
+package zap4echo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const defaultPanicThresholdWindow = time.Minute
+
+// Trips when more than threshold panics happen within a window.
+type panicBreaker struct {
+	log         *zap.Logger
+	threshold   int
+	window      time.Duration
+	onThreshold func()
+
+	mu sync.Mutex
+	// Ring buffer of the times of the last threshold+1 panics.
+	times []time.Time
+	next  int
+}
+
+func newPanicBreaker(log *zap.Logger, threshold int, window time.Duration, onThreshold func()) *panicBreaker {
+	return &panicBreaker{
+		// The summary is logged with fatal level, but exiting is up to onThreshold.
+		log:         log.WithOptions(zap.WithFatalHook(noopHook{})),
+		threshold:   threshold,
+		window:      window,
+		onThreshold: onThreshold,
+		times:       make([]time.Time, 0, threshold+1),
+	}
+}
+
+// Record a panic. If the threshold is exceeded, a summary is
+// logged and onThreshold is called.
+func (b *panicBreaker) record() {
+	now := time.Now()
+
+	b.mu.Lock()
+	if len(b.times) < cap(b.times) {
+		b.times = append(b.times, now)
+	} else {
+		b.times[b.next] = now
+		b.next = (b.next + 1) % len(b.times)
+	}
+	// After wrapping around, b.next is the index of the oldest panic.
+	tripped := len(b.times) == cap(b.times) && now.Sub(b.times[b.next]) <= b.window
+	if tripped {
+		// Start over, in case onThreshold doesn't exit.
+		b.times = b.times[:0]
+		b.next = 0
+	}
+	b.mu.Unlock()
+
+	if tripped {
+		b.log.Fatal(fmt.Sprintf("More than %d panics occurred within %s", b.threshold, b.window),
+			zap.Int("panic_threshold", b.threshold),
+			zap.Duration("panic_window", b.window),
+		)
+		b.onThreshold()
+	}
+}
+
+// zap replaces zapcore.WriteThenNoop with zapcore.WriteThenFatal
+// for fatal level, so a hook of our own is needed.
+type noopHook struct{}
+
+func (noopHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
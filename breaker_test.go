@@ -0,0 +1,71 @@
+package zap4echo
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecoverWithPanicThreshold(t *testing.T) {
+	tripped := 0
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		PanicThreshold: 2,
+		OnThreshold: func() {
+			tripped++
+		},
+	})
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("oops")
+	})
+
+	for i := 0; i < 2; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	}
+	assert.Equal(t, 0, tripped)
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	assert.Equal(t, 1, tripped)
+
+	all := logs.All()
+	assert.Equal(t, 4, len(all))
+	summary := all[3]
+	assert.Equal(t, zapcore.FatalLevel, summary.Level)
+	assert.Equal(t, "More than 2 panics occurred within 1m0s", summary.Message)
+	assert.Equal(t, int64(2), summary.ContextMap()["panic_threshold"].(int64))
+
+	// Counting starts over.
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	assert.Equal(t, 1, tripped)
+}
+
+func TestRecoverWithPanicThresholdWindow(t *testing.T) {
+	tripped := 0
+	log, _ := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		PanicThreshold:       1,
+		PanicThresholdWindow: 10 * time.Millisecond,
+		OnThreshold: func() {
+			tripped++
+		},
+	})
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("oops")
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	time.Sleep(20 * time.Millisecond)
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	assert.Equal(t, 0, tripped)
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	assert.Equal(t, 1, tripped)
+}
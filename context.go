@@ -0,0 +1,129 @@
+package zap4echo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Key under which the request scoped state is stored in echo.Context.
+const contextKey = "zap4echo"
+
+type loggerCtxKey struct{}
+
+type requestState struct {
+	log       *zap.Logger
+	start     time.Time
+	requestID string
+	trace     *traceContext
+	// Recoverer of the middleware. See Go.
+	recoverer *recoverer
+
+	mu       sync.Mutex
+	fields   []zap.Field
+	level    zapcore.Level
+	levelSet bool
+}
+
+func getState(c echo.Context) *requestState {
+	state, _ := c.Get(contextKey).(*requestState)
+	return state
+}
+
+func getOrCreateState(c echo.Context) *requestState {
+	state := getState(c)
+	if state == nil {
+		state = &requestState{}
+		c.Set(contextKey, state)
+	}
+	return state
+}
+
+// FromContext returns the request scoped logger set up by the logger middleware.
+// The logger already carries the `request_id`, `method`, `path` and `client_ip`
+// fields, so that the logs of the handler can be joined with the access log.
+//
+// If the logger middleware is not in use, zap.L() is returned.
+func FromContext(c echo.Context) *zap.Logger {
+	if state := getState(c); state != nil && state.log != nil {
+		return state.log
+	}
+	return FromStdContext(c.Request().Context())
+}
+
+// FromStdContext is the same as FromContext, but for context.Context.
+// It can be used with c.Request().Context() and its descendants.
+//
+// If no logger is stored in ctx, zap.L() is returned.
+func FromStdContext(ctx context.Context) *zap.Logger {
+	if log, ok := ctx.Value(loggerCtxKey{}).(*zap.Logger); ok {
+		return log
+	}
+	return zap.L()
+}
+
+func setRequestLogger(c echo.Context, log *zap.Logger) {
+	getOrCreateState(c).log = log
+	req := c.Request()
+	c.SetRequest(req.WithContext(context.WithValue(req.Context(), loggerCtxKey{}, log)))
+}
+
+// AddFields attaches fields to the log entry written by the logger middleware
+// at the end of the request. It can be called from handlers and from the
+// middlewares that come after the logger middleware.
+//
+// If the logger middleware is not in use, AddFields does nothing.
+func AddFields(c echo.Context, fields ...zap.Field) {
+	state := getState(c)
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	state.fields = append(state.fields, fields...)
+	state.mu.Unlock()
+}
+
+func (s *requestState) addedFields() []zap.Field {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fields
+}
+
+// SetLevel overrides the level of the log entry written by the logger middleware
+// for the current request. For example, an expected 404 can be downgraded to debug level.
+//
+// If the logger middleware is not in use, SetLevel does nothing.
+func SetLevel(c echo.Context, level zapcore.Level) {
+	state := getState(c)
+	if state == nil {
+		return
+	}
+	state.mu.Lock()
+	state.level = level
+	state.levelSet = true
+	state.mu.Unlock()
+}
+
+func (s *requestState) levelOverride() (zapcore.Level, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.level, s.levelSet
+}
+
+// A context that has the values of its parent, but is never canceled.
+// It is context.WithoutCancel, which requires Go 1.21.
+type withoutCancel struct {
+	parent context.Context
+}
+
+func (withoutCancel) Deadline() (deadline time.Time, ok bool) { return }
+func (withoutCancel) Done() <-chan struct{}                   { return nil }
+func (withoutCancel) Err() error                              { return nil }
+
+func (c withoutCancel) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
@@ -0,0 +1,96 @@
+package zap4echo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestFromContext(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Logger(log)
+	e := createTestEcho(m)
+
+	e.GET("/hello", func(c echo.Context) error {
+		FromContext(c).Info("From echo.Context")
+		FromStdContext(c.Request().Context()).Info("From context.Context")
+		return c.String(http.StatusOK, "Hello!")
+	})
+
+	r := httptest.NewRequest("GET", "/hello?a=b", nil)
+	r.Header.Set(echo.HeaderXRequestID, "1337")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 3, logs.Len())
+
+	for _, l := range logs.All()[:2] {
+		assert.Equal(t, "1337", l.ContextMap()["request_id"].(string))
+		assert.Equal(t, "GET", l.ContextMap()["method"].(string))
+		assert.Equal(t, "/hello?a=b", l.ContextMap()["path"].(string))
+		assert.Equal(t, "192.0.2.1", l.ContextMap()["client_ip"].(string))
+	}
+	assert.Equal(t, "From echo.Context", logs.All()[0].Message)
+	assert.Equal(t, "From context.Context", logs.All()[1].Message)
+	assert.Equal(t, DefaultLoggerMsg, logs.All()[2].Message)
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	e := echo.New()
+	r := httptest.NewRequest("GET", "/", nil)
+	c := e.NewContext(r, httptest.NewRecorder())
+
+	assert.Equal(t, zap.L(), FromContext(c))
+	assert.Equal(t, zap.L(), FromStdContext(r.Context()))
+}
+
+func TestAddFields(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Logger(log)
+	e := createTestEcho(m)
+
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			AddFields(c, zap.Bool("cache_hit", true))
+			return next(c)
+		}
+	})
+
+	e.GET("/hello", func(c echo.Context) error {
+		AddFields(c, zap.String("user_id", "42"), zap.Int("order_id", 7))
+		return c.String(http.StatusOK, "Hello!")
+	})
+
+	r := httptest.NewRequest("GET", "/hello", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	l := logs.All()[0]
+	assert.Equal(t, true, l.ContextMap()["cache_hit"].(bool))
+	assert.Equal(t, "42", l.ContextMap()["user_id"].(string))
+	assert.Equal(t, int64(7), l.ContextMap()["order_id"].(int64))
+}
+
+func TestWithoutCancel(t *testing.T) {
+	type key struct{}
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), key{}, "value"), time.Hour)
+	ctx := withoutCancel{parent}
+	cancel()
+
+	_, ok := ctx.Deadline()
+	assert.False(t, ok)
+	assert.Nil(t, ctx.Done())
+	assert.NoError(t, ctx.Err())
+	assert.Equal(t, "value", ctx.Value(key{}))
+}
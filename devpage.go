@@ -0,0 +1,157 @@
+package zap4echo
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Number of source lines shown before and after the line of an application frame.
+const devPageSourceContext = 5
+
+var devPageTemplate = template.Must(template.New("panic").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>panic: {{.Value}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h1 { color: #b00; font-size: 1.4em; word-break: break-word; }
+h2 { font-size: 1.1em; margin-top: 2em; }
+.frame { margin: 0.5em 0; }
+.frame .func { font-family: monospace; font-weight: bold; }
+.frame .file { font-family: monospace; color: #666; }
+.frame.internal .func { font-weight: normal; color: #666; }
+pre { background: #f6f6f6; padding: 0.5em; overflow-x: auto; }
+pre .current { background: #fdd; display: block; }
+table { border-collapse: collapse; }
+td { font-family: monospace; padding: 0.2em 1em 0.2em 0; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>panic: {{.Value}}</h1>
+<p>{{.Method}} {{.Path}}{{if .RequestID}} &middot; request ID {{.RequestID}}{{end}}</p>
+
+<h2>Stack trace</h2>
+{{range .Frames}}<div class="frame{{if not .Application}} internal{{end}}">
+<div class="func">{{.Function}}</div>
+<div class="file">{{.File}}:{{.Line}}</div>
+{{if .Source}}<pre>{{range .Source}}<span{{if .Current}} class="current"{{end}}>{{printf "%5d" .Number}}  {{.Text}}</span>
+{{end}}</pre>{{end}}
+</div>
+{{end}}
+{{if .Params}}<h2>Params</h2>
+<table>{{range .Params}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>{{end}}</table>
+{{end}}
+<h2>Headers</h2>
+<table>{{range .Headers}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>{{end}}</table>
+</body>
+</html>
+`))
+
+type devPage struct {
+	Value     string
+	Method    string
+	Path      string
+	RequestID string
+	Frames    []devPageFrame
+	Params    []devPagePair
+	Headers   []devPagePair
+}
+
+type devPageFrame struct {
+	StackFrame
+	Application bool
+	Source      []devPageLine
+}
+
+type devPageLine struct {
+	Number  int
+	Text    string
+	Current bool
+}
+
+type devPagePair struct {
+	Name  string
+	Value string
+}
+
+// Whether the development error page should be rendered for the request.
+// It is only rendered in debug mode of Echo, and to browsers.
+func wantsDevPage(c echo.Context) bool {
+	return c.Echo().Debug && strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMETextHTML)
+}
+
+// Render the development error page of the panic.
+func (r *recoverer) renderDevPage(c echo.Context, p *recoveredPanic) {
+	req := c.Request()
+	page := &devPage{
+		Value:     fmt.Sprint(p.value),
+		Method:    req.Method,
+		Path:      req.RequestURI,
+		RequestID: RequestID(c),
+	}
+
+	for _, frame := range p.frames {
+		if frame.isRuntime() {
+			continue
+		}
+		f := devPageFrame{StackFrame: frame, Application: frame.isApplication()}
+		if f.Application {
+			f.Source = sourceLines(frame.File, frame.Line, devPageSourceContext)
+		}
+		page.Frames = append(page.Frames, f)
+	}
+
+	values := c.ParamValues()
+	for i, name := range c.ParamNames() {
+		if i < len(values) {
+			page.Params = append(page.Params, devPagePair{Name: name, Value: values[i]})
+		}
+	}
+
+	redact := headerSet(r.fields.config.RedactHeaders)
+	for name, values := range req.Header {
+		value := strings.Join(values, ", ")
+		if _, ok := redact[http.CanonicalHeaderKey(name)]; ok {
+			value = redacted
+		}
+		page.Headers = append(page.Headers, devPagePair{Name: name, Value: value})
+	}
+	sort.Slice(page.Headers, func(i, j int) bool { return page.Headers[i].Name < page.Headers[j].Name })
+
+	var b strings.Builder
+	if err := devPageTemplate.Execute(&b, page); err != nil {
+		c.Logger().Error(err)
+		r.respond(c, p.err)
+		return
+	}
+	if err := c.HTML(http.StatusInternalServerError, b.String()); err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// Read the lines around the given line of a file.
+// Returns nil if the file cannot be read.
+func sourceLines(file string, line, context int) []devPageLine {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []devPageLine
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan() && n <= line+context; n++ {
+		if n >= line-context {
+			lines = append(lines, devPageLine{Number: n, Text: scanner.Text(), Current: n == line})
+		}
+	}
+	return lines
+}
@@ -0,0 +1,58 @@
+package zap4echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverWithDevErrorPage(t *testing.T) {
+	log, _ := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		DevErrorPage: true,
+	})
+	e := createTestEcho(m)
+	e.Debug = true
+
+	e.GET("/users/:id", func(c echo.Context) error {
+		panic("<dev page oops>")
+	})
+
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("GET", "/users/42", nil)
+		r.Header.Set(echo.HeaderAccept, "text/html,application/xhtml+xml")
+		r.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+		return r
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, newRequest())
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Header().Get(echo.HeaderContentType), echo.MIMETextHTML)
+	page := w.Body.String()
+	assert.Contains(t, page, "panic: &lt;dev page oops&gt;")
+	assert.Contains(t, page, "TestRecoverWithDevErrorPage")
+	// Source code of the frame
+	assert.Contains(t, page, `panic(&#34;&lt;dev page oops&gt;&#34;)`)
+	assert.Contains(t, page, "<td>id</td><td>42</td>")
+	assert.Contains(t, page, "<td>Authorization</td><td>[REDACTED]</td>")
+
+	// Not in production
+	e.Debug = false
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, newRequest())
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+
+	// Not to API clients
+	e.Debug = true
+	r := newRequest()
+	r.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	assert.Contains(t, w.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+}
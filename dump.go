@@ -0,0 +1,215 @@
+package zap4echo
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Dumps a redacted snapshot of a request for reproducing a panic.
+type requestDumper struct {
+	bodySize         int
+	bodyContentTypes []string
+	redactBodyKeys   []string
+	redactHeaders    map[string]struct{}
+	headers          *headerLogger
+	paths            *pathLogger
+	redactQuery      []string
+}
+
+// Create a requestDumper with the redaction rules of config.
+func newRequestDumper(config *LoggerConfig, bodySize int) *requestDumper {
+	contentTypes := config.RequestBodyContentTypes
+	if contentTypes == nil {
+		contentTypes = DefaultBodyContentTypes
+	}
+	return &requestDumper{
+		bodySize:         bodySize,
+		bodyContentTypes: contentTypes,
+		redactBodyKeys:   config.RedactBodyKeys,
+		redactHeaders:    headerSet(config.RedactHeaders),
+		headers:          newHeaderLogger(&HeaderFilter{}, config.RedactHeaders),
+		paths: newPathLogger(&LoggerConfig{
+			RedactQueryParams: config.RedactQueryParams,
+			ScrubPathPatterns: config.ScrubPathPatterns,
+		}),
+		redactQuery: config.RedactQueryParams,
+	}
+}
+
+// The beginning of the request body, read before the handler.
+type dumpedBody struct {
+	data      []byte
+	truncated bool
+}
+
+// Read the beginning of the request body before the handler runs.
+// The body is restored, so the handler reads it as is.
+func (d *requestDumper) readBody(c echo.Context) *dumpedBody {
+	req := c.Request()
+	if req.Body == nil || req.Body == http.NoBody ||
+		!matchContentType(req.Header.Get(echo.HeaderContentType), d.bodyContentTypes) {
+		return nil
+	}
+
+	// Read one more byte to tell whether the body is truncated.
+	data, err := io.ReadAll(io.LimitReader(req.Body, int64(d.bodySize)+1))
+
+	// The handler reads what is read here, followed by the rest of the body.
+	rest := io.Reader(req.Body)
+	if err != nil {
+		rest = errReader{err}
+	}
+	req.Body = teeReadCloser{Reader: io.MultiReader(bytes.NewReader(data), rest), Closer: req.Body}
+
+	body := &dumpedBody{data: data}
+	if len(data) > d.bodySize {
+		body.data = data[:d.bodySize]
+		body.truncated = true
+	}
+	return body
+}
+
+// A reader that returns the error the body was read with.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// Returns the `request_dump` and `curl` fields.
+func (d *requestDumper) fields(c echo.Context, body *dumpedBody) []zapcore.Field {
+	req := c.Request()
+	dump := requestDumpObject{
+		method:  req.Method,
+		headers: d.headers.object(req.Header),
+		params:  paramsObject{names: c.ParamNames(), values: c.ParamValues()},
+	}
+
+	path, _ := d.paths.path(req.RequestURI)
+	dump.path = path
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		dump.path = path[:i]
+	}
+	if query := req.URL.Query(); len(query) > 0 {
+		redactValues(query, d.redactQuery)
+		dump.query = queryObject(query)
+	}
+
+	var bodyString string
+	if body != nil {
+		bodyString = redactBody(body.data, req.Header.Get(echo.HeaderContentType), d.redactBodyKeys)
+		dump.body = &bodyString
+		dump.bodyTruncated = body.truncated
+	}
+
+	return []zapcore.Field{
+		zap.Object("request_dump", dump),
+		zap.String("curl", d.curl(c, path, bodyString, dump.bodyTruncated)),
+	}
+}
+
+// Headers that are not copied to the curl command. They describe the
+// connection or the encoding of the original body (which may be redacted
+// or truncated in the command), and curl sets them itself.
+var curlSkipHeaders = headerSet([]string{
+	echo.HeaderContentLength,
+	"Host",
+	echo.HeaderAcceptEncoding,
+	// Hop-by-hop headers.
+	echo.HeaderConnection,
+	"Keep-Alive",
+	"Proxy-Connection",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	echo.HeaderUpgrade,
+})
+
+// Generate a curl command that reproduces the request.
+//
+// A truncated body is left out, so that a partial body is not sent as if it
+// were the whole request. A shell comment at the end of the command tells so.
+func (d *requestDumper) curl(c echo.Context, path, body string, truncated bool) string {
+	req := c.Request()
+	var b strings.Builder
+	b.WriteString("curl -X ")
+	b.WriteString(req.Method)
+	b.WriteString(" ")
+	b.WriteString(shellQuote(c.Scheme() + "://" + req.Host + path))
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		if _, ok := curlSkipHeaders[http.CanonicalHeaderKey(name)]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			if _, ok := d.redactHeaders[http.CanonicalHeaderKey(name)]; ok {
+				value = redacted
+			}
+			b.WriteString(" -H ")
+			b.WriteString(shellQuote(name + ": " + value))
+		}
+	}
+
+	if truncated {
+		b.WriteString(" # request body is truncated, and left out")
+	} else if body != "" {
+		b.WriteString(" --data-raw ")
+		b.WriteString(shellQuote(body))
+	}
+	return b.String()
+}
+
+// Quote s with single quotes for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type requestDumpObject struct {
+	method        string
+	path          string
+	query         queryObject
+	params        paramsObject
+	headers       zapcore.ObjectMarshaler
+	body          *string
+	bodyTruncated bool
+}
+
+func (o requestDumpObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("method", o.method)
+	enc.AddString("path", o.path)
+	if len(o.query) > 0 {
+		if err := enc.AddObject("query", o.query); err != nil {
+			return err
+		}
+	}
+	if len(o.params.names) > 0 {
+		if err := enc.AddObject("params", o.params); err != nil {
+			return err
+		}
+	}
+	if err := enc.AddObject("headers", o.headers); err != nil {
+		return err
+	}
+	if o.body != nil {
+		enc.AddString("body", *o.body)
+		if o.bodyTruncated {
+			enc.AddBool("body_truncated", true)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package zap4echo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverWithDumpRequest(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		DumpRequest:         true,
+		DumpRequestBodySize: 64,
+		Fields: NewFieldBuilder(LoggerConfig{
+			RedactQueryParams: []string{"token"},
+			RedactBodyKeys:    []string{"password"},
+		}),
+	})
+	e := createTestEcho(m)
+
+	var handlerBody string
+	e.POST("/users/:id", func(c echo.Context) error {
+		b, _ := io.ReadAll(c.Request().Body)
+		handlerBody = string(b)
+		panic("oops")
+	})
+
+	const body = `{"name":"it's me","password":"hunter2"}`
+	r := httptest.NewRequest("POST", "/users/42?token=secret&page=1", strings.NewReader(body))
+	r.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	r.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, body, handlerBody, "handler should read the whole body")
+
+	l := logs.All()[0]
+	dump := l.ContextMap()["request_dump"].(map[string]interface{})
+	assert.Equal(t, "POST", dump["method"])
+	assert.Equal(t, "/users/42", dump["path"])
+	assert.Equal(t, map[string]interface{}{"token": "[REDACTED]", "page": "1"}, dump["query"])
+	assert.Equal(t, map[string]interface{}{"id": "42"}, dump["params"])
+	assert.Equal(t, "[REDACTED]", dump["headers"].(map[string]interface{})["Authorization"])
+	assert.Equal(t, `{"name":"it's me","password":"[REDACTED]"}`, dump["body"])
+	assert.Nil(t, dump["body_truncated"])
+
+	assert.Equal(t, `curl -X POST 'http://example.com/users/42?token=[REDACTED]&page=1'`+
+		` -H 'Authorization: [REDACTED]' -H 'Content-Type: application/json'`+
+		` --data-raw '{"name":"it'\''s me","password":"[REDACTED]"}'`,
+		l.ContextMap()["curl"].(string))
+}
+
+func TestRecoverWithDumpRequestBodySize(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		DumpRequest:         true,
+		DumpRequestBodySize: 5,
+	})
+	e := createTestEcho(m)
+
+	var handlerBody string
+	e.POST("/", func(c echo.Context) error {
+		b, _ := io.ReadAll(c.Request().Body)
+		handlerBody = string(b)
+		panic("oops")
+	})
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("Hello, World!"))
+	r.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	e.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "Hello, World!", handlerBody)
+
+	dump := logs.All()[0].ContextMap()["request_dump"].(map[string]interface{})
+	assert.Equal(t, "Hello", dump["body"])
+	assert.Equal(t, true, dump["body_truncated"])
+
+	curl := logs.All()[0].ContextMap()["curl"].(string)
+	assert.NotContains(t, curl, "--data-raw")
+	assert.Contains(t, curl, "# request body is truncated")
+}
+
+// httptest.NewRequest doesn't set the headers a real client sends,
+// such as Content-Length and Accept-Encoding.
+func TestRecoverWithDumpRequestFromServer(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		DumpRequest: true,
+		Fields: NewFieldBuilder(LoggerConfig{
+			RedactBodyKeys: []string{"password"},
+		}),
+	})
+	e := createTestEcho(m)
+
+	e.POST("/", func(c echo.Context) error {
+		panic("oops")
+	})
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Post(server.URL, echo.MIMEApplicationJSON, strings.NewReader(`{"password":"hunter2"}`))
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	curl := logs.All()[0].ContextMap()["curl"].(string)
+	assert.Contains(t, curl, `-H 'Content-Type: application/json'`)
+	assert.Contains(t, curl, `-H 'User-Agent: `)
+	assert.NotContains(t, curl, "Content-Length")
+	assert.NotContains(t, curl, "Accept-Encoding")
+	assert.True(t, strings.HasSuffix(curl, ` --data-raw '{"password":"[REDACTED]"}'`), curl)
+}
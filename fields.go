@@ -0,0 +1,216 @@
+package zap4echo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldBuilder builds the fields describing a request. It is used by both the
+// logger and the recover middlewares, so that the Omit* options, Schema and
+// redaction rules of LoggerConfig apply the same way to panic logs.
+//
+// Set RecoverConfig.Fields to share a FieldBuilder with the recover middleware.
+type FieldBuilder struct {
+	config LoggerConfig
+
+	paths           *pathLogger
+	routes          *routeNames
+	requestHeaders  *headerLogger
+	responseHeaders *headerLogger
+}
+
+// NewFieldBuilder creates a FieldBuilder from the field related options of config.
+// Options that are specific to the logger middleware (e.g. Skipper, LevelFunc,
+// body logging) are ignored.
+func NewFieldBuilder(config LoggerConfig) *FieldBuilder {
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = DefaultRedactedHeaders
+	}
+
+	b := &FieldBuilder{
+		config: config,
+		paths:  newPathLogger(&config),
+		routes: &routeNames{},
+	}
+
+	if config.LogRequestHeaders != nil {
+		b.requestHeaders = newHeaderLogger(config.LogRequestHeaders, config.RedactHeaders)
+	}
+	if config.LogResponseHeaders != nil {
+		b.responseHeaders = newHeaderLogger(config.LogResponseHeaders, config.RedactHeaders)
+	}
+	return b
+}
+
+// Fields returns the fields describing the request, followed by
+// the fields returned by LoggerConfig.FieldAdder.
+func (b *FieldBuilder) Fields(c echo.Context) []zapcore.Field {
+	fields := b.requestFields(c)
+	fields = b.config.Schema.apply(fields)
+	return b.customFields(c, fields)
+}
+
+// Called before the handler. Resolves the request ID and the trace context,
+// and marks the start of the request.
+func (b *FieldBuilder) begin(c echo.Context) {
+	config := &b.config
+	state := getOrCreateState(c)
+	if state.start.IsZero() {
+		state.start = time.Now()
+	}
+	resolveRequestID(c, config.CustomRequestIDHeader, config.RequestIDGenerator)
+	resolveTrace(c, config.TraceFormat, config.GenerateTraceID)
+}
+
+// Returns the trace context of the OpenTelemetry span if there is one,
+// or the trace context resolved from the headers.
+func (b *FieldBuilder) trace(c echo.Context) *traceContext {
+	// The span might be started after the middleware.
+	if t := spanTrace(c.Request().Context()); t != nil {
+		return t
+	}
+	if state := getState(c); state != nil {
+		return state.trace
+	}
+	return nil
+}
+
+func (b *FieldBuilder) latency(c echo.Context) zapcore.Field {
+	var latency time.Duration
+	if state := getState(c); state != nil && !state.start.IsZero() {
+		latency = time.Since(state.start)
+	}
+	return zap.Duration("latency", latency)
+}
+
+// Fields of the logger returned by FromContext.
+func (b *FieldBuilder) contextFields(c echo.Context) []zapcore.Field {
+	config := &b.config
+	req := c.Request()
+	fields := make([]zapcore.Field, 0, 8)
+
+	if !config.OmitRequestID {
+		requestID := getRequestID(c, config.CustomRequestIDHeader)
+		if requestID != "" {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+	}
+
+	fields = append(fields, b.trace(c).fields()...)
+
+	fields = append(fields, zap.String("method", req.Method))
+
+	if !config.OmitPath {
+		path, _ := b.paths.path(req.RequestURI)
+		fields = append(fields, zap.String("path", path))
+	}
+
+	if !config.OmitClientIP {
+		fields = append(fields, zap.String("client_ip", c.RealIP()))
+	}
+	return config.Schema.apply(fields)
+}
+
+// Fields describing the request. Schema is not applied.
+func (b *FieldBuilder) requestFields(c echo.Context) []zapcore.Field {
+	config := &b.config
+	req := c.Request()
+	resp := c.Response()
+	fields := make([]zapcore.Field, 0, 20)
+
+	fields = append(fields, []zapcore.Field{
+		zap.String("proto", req.Proto),
+		zap.String("host", req.Host),
+		zap.String("method", req.Method),
+	}...)
+
+	if !config.OmitClientIP {
+		fields = append(fields, zap.String("client_ip", c.RealIP()))
+	}
+
+	if !config.OmitUserAgent {
+		fields = append(fields, zap.String("user_agent", req.UserAgent()))
+	}
+
+	if !config.OmitPath {
+		path, query := b.paths.path(req.RequestURI)
+		fields = append(fields, zap.String("path", path))
+		if len(query) > 0 {
+			fields = append(fields, zap.Object("query", queryObject(query)))
+		}
+	}
+
+	if !config.OmitRoute {
+		if route := c.Path(); route != "" {
+			fields = append(fields, zap.String("route", route))
+		}
+	}
+
+	if !config.OmitRouteName {
+		if name := b.routes.get(c); name != "" {
+			fields = append(fields, zap.String("route_name", name))
+		}
+	}
+
+	if !config.OmitParams {
+		if names := c.ParamNames(); len(names) > 0 {
+			fields = append(fields, zap.Object("params", paramsObject{names: names, values: c.ParamValues()}))
+		}
+	}
+
+	if !config.OmitRequestID {
+		requestID := getRequestID(c, config.CustomRequestIDHeader)
+		if requestID != "" {
+			fields = append(fields, zap.String("request_id", requestID))
+		}
+	}
+
+	fields = append(fields, b.trace(c).fields()...)
+
+	if !config.OmitReferer {
+		referer := resp.Writer.Header().Get("Referer")
+		if referer == "" {
+			referer = req.Header.Get("Referer")
+		}
+		if referer != "" {
+			fields = append(fields, zap.String("referer", referer))
+		}
+	}
+
+	if b.requestHeaders != nil {
+		fields = append(fields, zap.Object("request_headers", b.requestHeaders.object(req.Header)))
+	}
+	return fields
+}
+
+// Fields describing the response. Schema is not applied.
+func (b *FieldBuilder) responseFields(c echo.Context) []zapcore.Field {
+	resp := c.Response()
+	fields := make([]zapcore.Field, 0, 5)
+
+	fields = append(fields, []zapcore.Field{
+		zap.Int("status", resp.Status),
+		zap.Int64("response_size", resp.Size),
+		b.latency(c),
+	}...)
+
+	if !b.config.OmitStatusText {
+		fields = append(fields, zap.String("status_text", http.StatusText(resp.Status)))
+	}
+
+	if b.responseHeaders != nil {
+		fields = append(fields, zap.Object("response_headers", b.responseHeaders.object(resp.Header())))
+	}
+	return fields
+}
+
+func (b *FieldBuilder) customFields(c echo.Context, fields []zapcore.Field) []zapcore.Field {
+	if b.config.FieldAdder != nil {
+		fields = append(fields, b.config.FieldAdder(c)...)
+	}
+	return fields
+}
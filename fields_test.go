@@ -0,0 +1,121 @@
+package zap4echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecoverWithRequestContext(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Recover(log)
+	e := createTestEcho(m)
+
+	e.GET("/panic/:id", func(c echo.Context) error {
+		if true {
+			panic("oops")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic/42", nil)
+	r.Host = "192.168.10.60:5252"
+	r.Header.Set("User-Agent", "AnHTTPClient")
+	r.Header.Set("Referer", "http://192.0.2.10")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, "HTTP/1.1", l.ContextMap()["proto"].(string))
+	assert.Equal(t, "192.168.10.60:5252", l.ContextMap()["host"].(string))
+	assert.Equal(t, "AnHTTPClient", l.ContextMap()["user_agent"].(string))
+	assert.Equal(t, "http://192.0.2.10", l.ContextMap()["referer"].(string))
+	assert.Equal(t, "/panic/:id", l.ContextMap()["route"].(string))
+	assert.Equal(t, map[string]interface{}{"id": "42"}, l.ContextMap()["params"])
+
+	_, ok := l.ContextMap()["latency"].(time.Duration)
+	assert.Equal(t, true, ok)
+}
+
+func TestRecoverWithSharedFieldBuilder(t *testing.T) {
+	fields := NewFieldBuilder(LoggerConfig{
+		OmitUserAgent:     true,
+		RedactQueryParams: []string{"token"},
+		Schema:            SchemaECS,
+		FieldAdder: func(c echo.Context) []zapcore.Field {
+			return []zapcore.Field{zap.String("hello", "world!")}
+		},
+	})
+
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		Fields: fields,
+		// Ignored in favor of the schema of the FieldBuilder
+		Schema: SchemaOTel,
+	})
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		if true {
+			panic("oops")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic?token=secret", nil)
+	r.Header.Set("User-Agent", "AnHTTPClient")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, "oops", l.ContextMap()["error.message"].(string))
+	assert.Equal(t, "/panic?token=[REDACTED]", l.ContextMap()["url.original"].(string))
+	assert.Equal(t, "world!", l.ContextMap()["hello"].(string))
+	assert.Nil(t, l.ContextMap()["user_agent.original"])
+	assert.Nil(t, l.ContextMap()["user_agent"])
+}
+
+func TestFieldBuilderFields(t *testing.T) {
+	fields := NewFieldBuilder(LoggerConfig{
+		OmitClientIP: true,
+	})
+
+	e := echo.New()
+	r := httptest.NewRequest("GET", "/hello", nil)
+	c := e.NewContext(r, httptest.NewRecorder())
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields.Fields(c) {
+		f.AddTo(enc)
+	}
+	m := enc.Fields
+
+	assert.Equal(t, "GET", m["method"])
+	assert.Equal(t, "/hello", m["path"])
+	assert.Nil(t, m["client_ip"])
+	assert.Nil(t, m["status"])
+}
+
+func TestMiddlewareUsesSameFieldsAsLogger(t *testing.T) {
+	log, logs := createTestZapLogger()
+	e := createTestEcho(Middleware(log, Config{}))
+
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	for _, key := range []string{"proto", "host", "method", "status", "response_size", "latency", "status_text", "client_ip", "user_agent", "path", "route"} {
+		assert.Contains(t, l.ContextMap(), key)
+	}
+}
@@ -0,0 +1,85 @@
+package zap4echo
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Number of application frames the fingerprint of a panic is computed from.
+const fingerprintFrames = 5
+
+// Compute the fingerprint of a panic from the type of the panic
+// value and the top frames of the application.
+func panicFingerprint(value interface{}, frames []StackFrame) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%T", value)
+
+	n := 0
+	for _, frame := range frames {
+		if !frame.isApplication() {
+			continue
+		}
+		h.Write([]byte{0})
+		h.Write([]byte(frame.Function))
+		h.Write([]byte{':'})
+		h.Write([]byte(strconv.Itoa(frame.Line)))
+		n++
+		if n == fingerprintFrames {
+			break
+		}
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// Counts the panics with the same fingerprint within a window, and logs
+// the number of occurrences at the end of the window.
+type panicDedup struct {
+	log    *zap.Logger
+	window time.Duration
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func newPanicDedup(log *zap.Logger, window time.Duration) *panicDedup {
+	return &panicDedup{
+		log:    log,
+		window: window,
+		counts: make(map[string]int),
+	}
+}
+
+// Reports whether a panic with the same fingerprint
+// has already been seen within the window.
+func (d *panicDedup) seen(fingerprint string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	count, ok := d.counts[fingerprint]
+	d.counts[fingerprint] = count + 1
+	if !ok {
+		time.AfterFunc(d.window, func() { d.flush(fingerprint) })
+	}
+	return ok
+}
+
+func (d *panicDedup) flush(fingerprint string) {
+	d.mu.Lock()
+	count := d.counts[fingerprint]
+	delete(d.counts, fingerprint)
+	d.mu.Unlock()
+
+	// Only the first panic was logged.
+	if count > 1 {
+		d.log.Warn(fmt.Sprintf("Panic with fingerprint %s occurred %d times", fingerprint, count),
+			zap.String("panic_fingerprint", fingerprint),
+			zap.Int("panic_count", count),
+			zap.Duration("panic_window", d.window),
+		)
+	}
+}
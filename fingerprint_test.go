@@ -0,0 +1,78 @@
+package zap4echo
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecoverWithPanicFingerprint(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Recover(log)
+	e := createTestEcho(m)
+
+	e.GET("/a", func(c echo.Context) error {
+		panic("oops")
+	})
+	e.GET("/b", func(c echo.Context) error {
+		var m map[string]int
+		m["oops"] = 1
+		return nil
+	})
+
+	for _, path := range []string{"/a", "/a", "/b"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	all := logs.All()
+	assert.Equal(t, 3, len(all))
+	a1 := all[0].ContextMap()["panic_fingerprint"].(string)
+	a2 := all[1].ContextMap()["panic_fingerprint"].(string)
+	b := all[2].ContextMap()["panic_fingerprint"].(string)
+	assert.NotEmpty(t, a1)
+	assert.Equal(t, a1, a2)
+	assert.NotEqual(t, a1, b)
+}
+
+func TestRecoverWithPanicDedupWindow(t *testing.T) {
+	const window = 50 * time.Millisecond
+
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		StackTrace:       true,
+		PanicDedupWindow: window,
+	})
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("oops")
+	})
+
+	for i := 0; i < 3; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	}
+
+	all := logs.All()
+	assert.Equal(t, 3, len(all))
+	assert.Contains(t, all[0].ContextMap(), "stacktrace")
+	assert.Nil(t, all[0].ContextMap()["panic_repeated"])
+	for _, l := range all[1:] {
+		assert.Nil(t, l.ContextMap()["stacktrace"])
+		assert.Equal(t, true, l.ContextMap()["panic_repeated"].(bool))
+	}
+	fingerprint := all[0].ContextMap()["panic_fingerprint"].(string)
+
+	assert.Eventually(t, func() bool { return logs.Len() == 4 }, time.Second, 10*time.Millisecond)
+	summary := logs.All()[3]
+	assert.Equal(t, zapcore.WarnLevel, summary.Level)
+	assert.Equal(t, "Panic with fingerprint "+fingerprint+" occurred 3 times", summary.Message)
+	assert.Equal(t, int64(3), summary.ContextMap()["panic_count"].(int64))
+
+	// The window has ended.
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	assert.Contains(t, logs.All()[4].ContextMap(), "stacktrace")
+}
@@ -0,0 +1,119 @@
+package zap4echo
+
+import (
+	"context"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Go runs fn in a new goroutine, and recovers from its panics.
+//
+// A panic in a goroutine started by a handler is not covered by the recover
+// middleware, and crashes the whole server. With Go, the panic is logged with the
+// options of the recover middleware (or Middleware) that serves the request, along
+// with the `request_id`, trace context, `method`, `path` and `client_ip` fields,
+// and the `goroutine` field set to true.
+//
+// Everything needed is taken from c when Go is called, as c must not be used after
+// the handler returns. ctx is the context of the request without its cancellation,
+// so fn can outlive the request while having the values of it (e.g. the
+// OpenTelemetry span, and the logger returned by FromStdContext).
+//
+// The panic is reported with Reporter. DumpRequest, ProblemDetails,
+// FieldAdder and ErrorHandler are not used.
+//
+// If no recover middleware serves the request, the logger returned by
+// FromContext is used with the default options. If that logger doesn't log
+// errors (e.g. it is zap.L() which is a no-op unless replaced), the panic is
+// not recovered, so that it is not swallowed silently.
+func Go(c echo.Context, fn func(ctx context.Context)) {
+	ctx := withoutCancel{c.Request().Context()}
+
+	var (
+		r         *recoverer
+		log       *zap.Logger
+		requestID = RequestID(c)
+		fields    []zapcore.Field
+	)
+	if state := getState(c); state != nil && state.recoverer != nil {
+		r = state.recoverer
+		fields = r.fields.contextFields(c)
+	} else {
+		log = FromContext(c)
+		if !log.Core().Enabled(zapcore.ErrorLevel) {
+			go fn(ctx)
+			return
+		}
+	}
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				r := r
+				if r == nil {
+					r = newRecoverer(log, defaultRecoverConfig)
+				}
+				r.recoveredGoroutine(ctx, err, requestID, fields)
+			}
+		}()
+		fn(ctx)
+	}()
+}
+
+// SafeGo runs fn in a new goroutine, and logs its panics instead of crashing.
+func SafeGo(log *zap.Logger, fn func()) {
+	SafeGoWithConfig(log, defaultRecoverConfig, fn)
+}
+
+// SafeGoWithConfig is SafeGo with the stack trace options of config.
+//
+// As there is no request, only the stack trace options,
+// CustomMsg and Reporter are used.
+func SafeGoWithConfig(log *zap.Logger, config RecoverConfig, fn func()) {
+	r := newRecoverer(log, config)
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				r.recoveredGoroutine(context.Background(), err, "", nil)
+			}
+		}()
+		fn()
+	}()
+}
+
+// Log the panic of a goroutine started with Go or SafeGo. This must be called in
+// the deferred function that recovers, so that the stack trace can be captured.
+//
+// requestID and fields are of the request, with Schema applied to fields.
+func (r *recoverer) recoveredGoroutine(ctx context.Context, value interface{}, requestID string, fields []zapcore.Field) {
+	p := newRecoveredPanic(value)
+	// Start from the deferred function that has recovered.
+	r.capture(p, 1)
+	recordPanic(ctx, p.value, p.stack)
+
+	panicFields := append([]zapcore.Field{zap.Bool("goroutine", true)}, p.fields...)
+	fields = append(fields, r.fields.config.Schema.apply(panicFields)...)
+
+	msg := func() string {
+		if r.config.CustomMsg == "" {
+			return DefaultRecoverMsg
+		} else {
+			return r.config.CustomMsg
+		}
+	}()
+	r.log.Error(msg, fields...)
+
+	if r.config.Reporter != nil {
+		event := p.event()
+		event.RequestID = requestID
+		event.Goroutine = true
+		event.Fields = fieldsMap(fields)
+		r.report(ctx, event)
+	}
+	if r.breaker != nil {
+		r.breaker.record()
+	}
+}
@@ -0,0 +1,103 @@
+package zap4echo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestGo(t *testing.T) {
+	const requestID = "31337"
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		StackTrace: true,
+	})
+	e := createTestEcho(m)
+
+	requestDone := make(chan struct{})
+	done := make(chan error, 1)
+	e.GET("/background", func(c echo.Context) error {
+		Go(c, func(ctx context.Context) {
+			// Wait until the request is done, and its context is canceled.
+			<-requestDone
+			done <- ctx.Err()
+			panic("oops")
+		})
+		return c.NoContent(http.StatusAccepted)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/background", nil).WithContext(ctx)
+	r.Header.Set(DefaultRequestIDHeader, requestID)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+	cancel()
+	assert.Error(t, r.Context().Err())
+	close(requestDone)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.NoError(t, <-done, "context should not be canceled with the request")
+
+	assert.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, 10*time.Millisecond)
+	l := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, l.Level)
+	assert.Equal(t, DefaultRecoverMsg, l.Message)
+	assert.Equal(t, "oops", l.ContextMap()["error"].(string))
+	assert.Equal(t, true, l.ContextMap()["goroutine"].(bool))
+	assert.Equal(t, requestID, l.ContextMap()["request_id"].(string))
+	assert.Equal(t, "/background", l.ContextMap()["path"].(string))
+	assert.Contains(t, l.ContextMap()["stacktrace"].(string), "TestGo")
+}
+
+func TestSafeGo(t *testing.T) {
+	log, logs := createTestZapLogger()
+
+	SafeGoWithConfig(log, RecoverConfig{CustomMsg: "Goroutine panicked"}, func() {
+		panic("oops")
+	})
+
+	assert.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, 10*time.Millisecond)
+	l := logs.All()[0]
+	assert.Equal(t, "Goroutine panicked", l.Message)
+	assert.Equal(t, "oops", l.ContextMap()["error"].(string))
+	assert.Equal(t, true, l.ContextMap()["goroutine"].(bool))
+	assert.NotEmpty(t, l.ContextMap()["panic_fingerprint"])
+
+	SafeGo(log, func() {
+		panic("oops")
+	})
+
+	assert.Eventually(t, func() bool { return logs.Len() == 2 }, time.Second, 10*time.Millisecond)
+	l = logs.All()[1]
+	assert.Equal(t, DefaultRecoverMsg, l.Message)
+	assert.Equal(t, "oops", l.ContextMap()["error"].(string))
+}
+
+func TestGoWithoutMiddleware(t *testing.T) {
+	log, logs := createTestZapLogger()
+	defer zap.ReplaceGlobals(log)()
+
+	e := echo.New()
+	e.GET("/background", func(c echo.Context) error {
+		Go(c, func(ctx context.Context) {
+			panic("oops")
+		})
+		return c.NoContent(http.StatusAccepted)
+	})
+
+	r := httptest.NewRequest("GET", "/background", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Eventually(t, func() bool { return logs.Len() == 1 }, time.Second, 10*time.Millisecond)
+	l := logs.All()[0]
+	assert.Equal(t, "oops", l.ContextMap()["error"].(string))
+	assert.Equal(t, true, l.ContextMap()["goroutine"].(bool))
+}
@@ -0,0 +1,80 @@
+package zap4echo
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Headers whose values are masked by default.
+var DefaultRedactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// HeaderFilter decides which headers are logged.
+// The zero value logs all headers.
+type HeaderFilter struct {
+	// If not empty, only these headers are logged.
+	Allowlist []string
+	// These headers are not logged.
+	Denylist []string
+}
+
+type headerLogger struct {
+	allow  map[string]struct{}
+	deny   map[string]struct{}
+	redact map[string]struct{}
+}
+
+func newHeaderLogger(filter *HeaderFilter, redact []string) *headerLogger {
+	return &headerLogger{
+		allow:  headerSet(filter.Allowlist),
+		deny:   headerSet(filter.Denylist),
+		redact: headerSet(redact),
+	}
+}
+
+func headerSet(headers []string) map[string]struct{} {
+	if len(headers) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(headers))
+	for _, h := range headers {
+		set[http.CanonicalHeaderKey(h)] = struct{}{}
+	}
+	return set
+}
+
+func (l *headerLogger) object(h http.Header) zapcore.ObjectMarshaler {
+	return headersObject{header: h, logger: l}
+}
+
+type headersObject struct {
+	header http.Header
+	logger *headerLogger
+}
+
+func (o headersObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	l := o.logger
+	for name, values := range o.header {
+		name = http.CanonicalHeaderKey(name)
+		if l.allow != nil {
+			if _, ok := l.allow[name]; !ok {
+				continue
+			}
+		}
+		if _, ok := l.deny[name]; ok {
+			continue
+		}
+		if _, ok := l.redact[name]; ok {
+			enc.AddString(name, redacted)
+			continue
+		}
+		enc.AddString(name, strings.Join(values, ", "))
+	}
+	return nil
+}
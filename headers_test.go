@@ -0,0 +1,86 @@
+package zap4echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerWithHeaders(t *testing.T) {
+	config := LoggerConfig{
+		LogRequestHeaders: &HeaderFilter{
+			Denylist: []string{"x-internal"},
+		},
+		LogResponseHeaders: &HeaderFilter{
+			Allowlist: []string{"Content-Type", "Set-Cookie"},
+		},
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/hello", func(c echo.Context) error {
+		c.SetCookie(&http.Cookie{Name: "session", Value: "secret"})
+		c.Response().Header().Set("X-Custom", "custom")
+		return c.String(http.StatusOK, "Hello!")
+	})
+
+	r := httptest.NewRequest("GET", "/hello", nil)
+	r.Header.Set("Accept", "text/plain")
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("X-Api-Key", "secret")
+	r.Header.Set("X-Internal", "1")
+	r.Header.Add("X-Multi", "a")
+	r.Header.Add("X-Multi", "b")
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	l := logs.All()[0]
+	assert.Equal(t, map[string]interface{}{
+		"Accept":        "text/plain",
+		"Authorization": "[REDACTED]",
+		"X-Api-Key":     "[REDACTED]",
+		"X-Multi":       "a, b",
+	}, l.ContextMap()["request_headers"])
+	assert.Equal(t, map[string]interface{}{
+		"Content-Type": echo.MIMETextPlainCharsetUTF8,
+		"Set-Cookie":   "[REDACTED]",
+	}, l.ContextMap()["response_headers"])
+}
+
+func TestLoggerWithCustomRedactHeaders(t *testing.T) {
+	config := LoggerConfig{
+		LogRequestHeaders: &HeaderFilter{},
+		RedactHeaders:     []string{"X-Secret"},
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Secret", "secret")
+	r.Header.Set("Authorization", "Basic Zm9v")
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, map[string]interface{}{
+		"X-Secret":      "[REDACTED]",
+		"Authorization": "Basic Zm9v",
+	}, l.ContextMap()["request_headers"])
+	assert.Nil(t, l.ContextMap()["response_headers"])
+}
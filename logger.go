@@ -1,8 +1,9 @@
 package zap4echo
 
 import (
+	"errors"
 	"net/http"
-	"time"
+	"regexp"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -40,12 +41,113 @@ type LoggerConfig struct {
 	OmitPath       bool
 	OmitRequestID  bool
 	OmitReferer    bool
+	OmitRoute      bool
+	// Echo names the routes that are not named explicitly after their handler
+	// functions (e.g. `main.getUser` or `main.main.func1`). Set this if the
+	// routes are not named, so that `route_name` is not logged for them.
+	OmitRouteName bool
+	OmitParams    bool
+
+	// If true, the error returned by the handler will not be logged.
+	//
+	// By default, it is logged with the `error` field. If it is an *echo.HTTPError,
+	// its message is logged with the `error` field, and its code and internal error
+	// are logged with the `error_code` and `error_internal` fields. If the
+	// *echo.HTTPError is wrapped, the message of the whole error is logged
+	// with the `error_wrapped` field.
+	OmitError bool
 
 	// Custom header name for request ID
 	CustomRequestIDHeader string
 
+	// If set, a request ID is generated with this function if the request has none.
+	// It is set to the response header, and can be retrieved with RequestID.
+	//
+	// Built-in generators are GenerateUUIDv4, GenerateUUIDv7 and GenerateULID.
+	RequestIDGenerator func() string
+
+	// Trace context headers to parse. If set, `trace_id`, `span_id`,
+	// `trace_sampled` and `trace_state` (W3C only) fields are logged.
+	TraceFormat TraceFormat
+	// If true, a new trace ID is started when the request has no trace context.
+	GenerateTraceID bool
+
+	// Keys of the logged fields. Presets are SchemaECS, SchemaOTel and SchemaGCP.
+	// Defaults to the keys documented in README.
+	Schema Schema
+
+	// If true, the request body will be logged with the `request_body` field.
+	//
+	// The body is captured while the handler reads it, so it is not altered.
+	// Only the part the handler has read is logged. If the handler has not read
+	// the body (e.g. it rejected the request early), the field is omitted.
+	// If the body is larger than RequestBodyMaxSize, it is truncated and the
+	// `request_body_truncated` field is added.
+	LogRequestBody bool
+	// Maximum size of the logged request body. Defaults to 4 KB
+	// (also if it is negative).
+	RequestBodyMaxSize int
+	// Content types of the request bodies to be logged.
+	// Defaults to DefaultBodyContentTypes.
+	RequestBodyContentTypes []string
+	// Only log the request body if the status code is 4XX or 5XX,
+	// or the handler returns an error.
+	RequestBodyErrorOnly bool
+	// Keys to redact from JSON and form bodies (e.g. `password`, `token`).
+	//
+	// A key without a dot matches at any depth. A key with dots
+	// (e.g. `user.password`) matches the path from the root of the JSON document.
+	RedactBodyKeys []string
+
+	// If true, the response body will be logged with the `response_body` field.
+	//
+	// If the body is larger than ResponseBodyMaxSize, it is truncated and the
+	// `response_body_truncated` field is added.
+	LogResponseBody bool
+	// Maximum size of the logged response body. Defaults to 4 KB
+	// (also if it is negative).
+	ResponseBodyMaxSize int
+	// Content types of the response bodies to be logged.
+	// Defaults to DefaultBodyContentTypes.
+	ResponseBodyContentTypes []string
+	// Only log the response body if the status code is 4XX or 5XX.
+	// Useful for logging the error message the client has seen.
+	ResponseBodyErrorOnly bool
+
+	// If set, request headers that pass the filter will be
+	// logged with the `request_headers` field as a nested object.
+	LogRequestHeaders *HeaderFilter
+	// If set, response headers that pass the filter will be
+	// logged with the `response_headers` field as a nested object.
+	LogResponseHeaders *HeaderFilter
+	// Headers whose values are masked. Defaults to DefaultRedactedHeaders.
+	RedactHeaders []string
+
+	// Query parameters whose values are masked in the `path` field
+	// (e.g. `access_token`).
+	RedactQueryParams []string
+	// Patterns to scrub from the `path` field. Matches are replaced with `[REDACTED]`.
+	// If a pattern has capturing groups, only the groups are replaced.
+	ScrubPathPatterns []*regexp.Regexp
+	// If true, the query is logged with the `query` field as
+	// a nested object, and it is omitted from the `path` field.
+	LogQueryAsObject bool
+
 	// A function for adding custom fields depending on the context.
 	FieldAdder func(c echo.Context) []zapcore.Field
+
+	// If true, errors returned by the handler are responded with `application/problem+json` (RFC 7807)
+	// instead of the HTTP error handler of Echo.
+	ProblemDetails bool
+	// Maps errors to problems. Defaults to DefaultProblemMapper.
+	ProblemMapper func(c echo.Context, err error) *Problem
+
+	// A function for deciding the log level depending on the context,
+	// the status code, and the error returned by the handler.
+	// Defaults to DefaultLevelFunc.
+	//
+	// The level can be overridden for the current request with SetLevel.
+	LevelFunc func(c echo.Context, status int, err error) zapcore.Level
 }
 
 func Logger(log *zap.Logger) echo.MiddlewareFunc {
@@ -53,6 +155,37 @@ func Logger(log *zap.Logger) echo.MiddlewareFunc {
 }
 
 func LoggerWithConfig(log *zap.Logger, config LoggerConfig) echo.MiddlewareFunc {
+	l := newRequestLogger(log, config)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := l.begin(c)
+			defer r.restore()
+
+			herr := next(c)
+			if herr != nil {
+				l.respond(c, herr)
+			}
+
+			l.write(c, r, herr, nil)
+
+			// We already handled error with c.Error
+			return nil
+		}
+	}
+}
+
+type requestLogger struct {
+	log *zap.Logger
+	// Logger for handlers. See FromContext.
+	requestLog *zap.Logger
+	config     LoggerConfig
+	fields     *FieldBuilder
+}
+
+func newRequestLogger(log *zap.Logger, config LoggerConfig) *requestLogger {
+	l := &requestLogger{requestLog: log}
+
 	if !config.IncludeCaller {
 		log = log.WithOptions(zap.WithCaller(false))
 	}
@@ -61,107 +194,218 @@ func LoggerWithConfig(log *zap.Logger, config LoggerConfig) echo.MiddlewareFunc
 		log = log.WithOptions(zap.AddStacktrace(zap.FatalLevel + 1))
 	}
 
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			start := time.Now()
-			herr := next(c)
-			if herr != nil {
-				c.Error(herr)
-			}
+	if config.LevelFunc == nil {
+		config.LevelFunc = DefaultLevelFunc
+	}
 
-			if config.Skipper != nil && config.Skipper(c) {
-				return nil
-			}
+	if config.LogRequestBody {
+		if config.RequestBodyMaxSize <= 0 {
+			config.RequestBodyMaxSize = defaultBodyMaxSize
+		}
+		if config.RequestBodyContentTypes == nil {
+			config.RequestBodyContentTypes = DefaultBodyContentTypes
+		}
+	}
 
-			resp := c.Response()
-			req := c.Request()
+	if config.LogResponseBody {
+		if config.ResponseBodyMaxSize <= 0 {
+			config.ResponseBodyMaxSize = defaultBodyMaxSize
+		}
+		if config.ResponseBodyContentTypes == nil {
+			config.ResponseBodyContentTypes = DefaultBodyContentTypes
+		}
+	}
 
-			if config.ErrorOnly && (resp.Status < 300 && herr == nil) {
-				return nil
-			}
+	l.log = log
+	l.config = config
+	l.fields = NewFieldBuilder(config)
+	return l
+}
 
-			latency := time.Since(start)
-			fields := make([]zapcore.Field, 0, 15)
+func (l *requestLogger) respond(c echo.Context, err error) {
+	if l.config.ProblemDetails {
+		respondProblem(c, err, l.config.ProblemMapper)
+	} else {
+		c.Error(err)
+	}
+}
 
-			fields = append(fields, []zapcore.Field{
-				zap.String("proto", req.Proto),
-				zap.String("host", req.Host),
-				zap.String("method", req.Method),
-				zap.Int("status", resp.Status),
-				zap.Int64("response_size", resp.Size),
-				zap.Duration("latency", latency),
-			}...)
+// State of a request from the start to the end of the handler.
+type loggedRequest struct {
+	requestBody  *limitedBuffer
+	responseBody *limitedBuffer
 
-			if !config.OmitStatusText {
-				fields = append(fields, zap.String("status_text", http.StatusText(resp.Status)))
-			}
+	resp   *echo.Response
+	writer http.ResponseWriter
+}
 
-			if !config.OmitClientIP {
-				fields = append(fields, zap.String("client_ip", c.RealIP()))
-			}
+// Called before the handler.
+func (l *requestLogger) begin(c echo.Context) *loggedRequest {
+	config := &l.config
+	r := &loggedRequest{}
 
-			if !config.OmitUserAgent {
-				fields = append(fields, zap.String("user_agent", req.UserAgent()))
-			}
+	l.fields.begin(c)
+	setRequestLogger(c, l.requestLog.With(l.fields.contextFields(c)...))
 
-			if !config.OmitPath {
-				// Use RequestURI instead of URL.Path.
-				// See: https://github.com/golang/go/issues/2782
-				fields = append(fields, zap.String("path", req.RequestURI))
-			}
+	if config.LogRequestBody {
+		req := c.Request()
+		if req.Body != nil && req.Body != http.NoBody &&
+			matchContentType(req.Header.Get(echo.HeaderContentType), config.RequestBodyContentTypes) {
+			req.Body, r.requestBody = captureRequestBody(req.Body, config.RequestBodyMaxSize)
+		}
+	}
 
-			if !config.OmitRequestID {
-				requestIDHeader := func() string {
-					if config.CustomRequestIDHeader != "" {
-						return config.CustomRequestIDHeader
-					} else {
-						return DefaultRequestIDHeader
-					}
-				}()
-				requestID := req.Header.Get(requestIDHeader)
-				if requestID == "" {
-					requestID = resp.Header().Get(requestIDHeader)
-				}
-				if requestID != "" {
-					fields = append(fields, zap.String("request_id", requestID))
-				}
-			}
+	if config.LogResponseBody {
+		r.resp = c.Response()
+		r.writer = r.resp.Writer
+		r.responseBody = newLimitedBuffer(config.ResponseBodyMaxSize)
+		r.resp.Writer = &responseBodyWriter{ResponseWriter: r.writer, buf: r.responseBody}
+	}
+	return r
+}
 
-			if !config.OmitReferer {
-				referer := resp.Writer.Header().Get("Referer")
-				if referer == "" {
-					referer = req.Header.Get("Referer")
-				}
-				if referer != "" {
-					fields = append(fields, zap.String("referer", referer))
-				}
-			}
+// Restore the response writer replaced for capturing the response body.
+func (r *loggedRequest) restore() {
+	if r.resp != nil {
+		r.resp.Writer = r.writer
+	}
+}
 
-			if config.FieldAdder != nil {
-				fields = append(fields, config.FieldAdder(c)...)
-			}
+// Called after the handler, and after the error is handled.
+//
+// If p is not nil, the handler has panicked. The request is logged
+// with error level regardless of Skipper, ErrorOnly and LevelFunc
+// (or with debug level if the panic is http.ErrAbortHandler).
+//
+// Returns the fields of the log entry, or nil if the request was not logged.
+func (l *requestLogger) write(c echo.Context, r *loggedRequest, herr error, p *recoveredPanic) []zapcore.Field {
+	config := &l.config
+	panicked := p != nil
 
-			s := resp.Status
-			msg := func() string {
-				if config.CustomMsg == "" {
-					return DefaultLoggerMsg
-				} else {
-					return config.CustomMsg
-				}
-			}()
-			switch {
-			case s >= 500:
-				log.Error(msg, fields...)
-			case s >= 400:
-				log.Warn(msg, fields...)
-			case s >= 300:
-				log.Info(msg, fields...)
-			default:
-				log.Info(msg, fields...)
-			}
+	if !panicked && config.Skipper != nil && config.Skipper(c) {
+		return nil
+	}
 
-			// We already handled error with c.Error
-			return nil
+	resp := c.Response()
+	req := c.Request()
+
+	if !panicked && config.ErrorOnly && (resp.Status < 300 && herr == nil) {
+		return nil
+	}
+
+	msg := func() string {
+		if config.CustomMsg == "" {
+			return DefaultLoggerMsg
+		} else {
+			return config.CustomMsg
+		}
+	}()
+
+	level := config.LevelFunc(c, resp.Status, herr)
+	if state := getState(c); state != nil {
+		if lvl, ok := state.levelOverride(); ok {
+			level = lvl
+		}
+	}
+	if panicked {
+		level = zapcore.ErrorLevel
+		if p.aborted {
+			level = zapcore.DebugLevel
+		}
+	}
+	ce := l.log.Check(level, msg)
+	if ce == nil {
+		return nil
+	}
+
+	fields := l.fields.requestFields(c)
+	fields = append(fields, l.fields.responseFields(c)...)
+
+	if !config.OmitError && herr != nil {
+		fields = append(fields, errorFields(herr)...)
+	}
+
+	if panicked {
+		fields = append(fields, zap.Bool("panic", true))
+		fields = append(fields, p.fields...)
+	}
+
+	if r.requestBody != nil && r.requestBody.buf.Len() > 0 && (!config.RequestBodyErrorOnly || resp.Status >= 400 || herr != nil) {
+		body := redactBody(r.requestBody.buf.Bytes(), req.Header.Get(echo.HeaderContentType), config.RedactBodyKeys)
+		fields = append(fields, zap.String("request_body", body))
+		if r.requestBody.truncated {
+			fields = append(fields, zap.Bool("request_body_truncated", true))
+		}
+	}
+
+	if r.responseBody != nil && (!config.ResponseBodyErrorOnly || resp.Status >= 400) &&
+		matchContentType(resp.Header().Get(echo.HeaderContentType), config.ResponseBodyContentTypes) {
+		fields = append(fields, zap.String("response_body", r.responseBody.buf.String()))
+		if r.responseBody.truncated {
+			fields = append(fields, zap.Bool("response_body_truncated", true))
+		}
+	}
+
+	fields = config.Schema.apply(fields)
+	fields = l.fields.customFields(c, fields)
+
+	if panicked {
+		fields = append(fields, p.customFields...)
+	}
+
+	if state := getState(c); state != nil {
+		fields = append(fields, state.addedFields()...)
+	}
+
+	ce.Write(fields...)
+	return fields
+}
+
+// DefaultLevelFunc logs 5XX responses with error level, 4XX responses
+// with warn level, and the rest with info level.
+func DefaultLevelFunc(c echo.Context, status int, err error) zapcore.Level {
+	switch {
+	case status >= 500:
+		return zapcore.ErrorLevel
+	case status >= 400:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+func errorFields(err error) []zapcore.Field {
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		return []zapcore.Field{zap.Error(err)}
+	}
+
+	fields := []zapcore.Field{
+		zap.Any("error", he.Message),
+		zap.Int("error_code", he.Code),
+	}
+	if he.Internal != nil {
+		fields = append(fields, zap.NamedError("error_internal", he.Internal))
+	}
+	if err != he {
+		fields = append(fields, zap.String("error_wrapped", err.Error()))
+	}
+	return fields
+}
+
+// Get the request ID from the request header, or from the
+// response header if it was set by the server.
+func getRequestID(c echo.Context, customHeader string) string {
+	requestIDHeader := func() string {
+		if customHeader != "" {
+			return customHeader
+		} else {
+			return DefaultRequestIDHeader
 		}
+	}()
+	requestID := c.Request().Header.Get(requestIDHeader)
+	if requestID == "" {
+		requestID = c.Response().Header().Get(requestIDHeader)
 	}
+	return requestID
 }
@@ -294,6 +294,10 @@ func TestLoggerWithEverythingOmitted(t *testing.T) {
 		OmitPath:       true,
 		OmitRequestID:  true,
 		OmitReferer:    true,
+		OmitError:      true,
+		OmitRoute:      true,
+		OmitRouteName:  true,
+		OmitParams:     true,
 	}
 
 	log, logs := createTestZapLogger()
@@ -307,8 +311,9 @@ func TestLoggerWithEverythingOmitted(t *testing.T) {
 		}
 	})
 
-	e.GET("/hello", func(c echo.Context) error {
-		return c.String(http.StatusInternalServerError, "Hello!")
+	e.GET("/:name", func(c echo.Context) error {
+		c.String(http.StatusInternalServerError, "Hello!")
+		return fmt.Errorf("intentional")
 	})
 
 	r := httptest.NewRequest("GET", "/hello", nil)
@@ -341,6 +346,126 @@ func TestLoggerWithEverythingOmitted(t *testing.T) {
 	assert.Nil(t, l.ContextMap()["path"])
 	assert.Nil(t, l.ContextMap()["request_id"])
 	assert.Nil(t, l.ContextMap()["referer"])
+	assert.Nil(t, l.ContextMap()["error"])
+	assert.Nil(t, l.ContextMap()["route"])
+	assert.Nil(t, l.ContextMap()["route_name"])
+	assert.Nil(t, l.ContextMap()["params"])
+}
+
+func TestLoggerWithRoute(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Logger(log)
+	e := createTestEcho(m)
+
+	e.GET("/users/:id/orders/:order", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}).Name = "user-order"
+
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest("GET", "/users/42/orders/7", nil)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+
+		res := w.Result()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		l := logs.All()[i]
+		assert.Equal(t, "/users/:id/orders/:order", l.ContextMap()["route"].(string))
+		assert.Equal(t, "user-order", l.ContextMap()["route_name"].(string))
+		assert.Equal(t, map[string]interface{}{
+			"id":    "42",
+			"order": "7",
+		}, l.ContextMap()["params"])
+	}
+}
+
+func TestLoggerWithUnmatchedRoute(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Logger(log)
+	e := createTestEcho(m)
+
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/nowhere", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, int64(http.StatusNotFound), l.ContextMap()["status"].(int64))
+	assert.Nil(t, l.ContextMap()["route"])
+	assert.Nil(t, l.ContextMap()["route_name"])
+}
+
+func TestRouteNamesCachesMisses(t *testing.T) {
+	e := echo.New()
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	// No route for the method, e.g. 405.
+	c := e.NewContext(httptest.NewRequest("POST", "/users/42", nil), httptest.NewRecorder())
+	c.SetPath("/users/:id")
+
+	var names routeNames
+	assert.Equal(t, "", names.get(c))
+	name, ok := names.names.Load("POST /users/:id")
+	assert.True(t, ok)
+	assert.Equal(t, "", name)
+}
+
+func TestLoggerWithError(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Logger(log)
+	e := createTestEcho(m)
+
+	e.GET("/error", func(c echo.Context) error {
+		return fmt.Errorf("intentional")
+	})
+
+	e.GET("/httperror", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "bad input").SetInternal(fmt.Errorf("internal"))
+	})
+
+	e.GET("/wrapped", func(c echo.Context) error {
+		return fmt.Errorf("wrapped: %w", echo.NewHTTPError(http.StatusBadRequest, "bad input"))
+	})
+
+	r := httptest.NewRequest("GET", "/error", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	l := logs.All()[0]
+	assert.Equal(t, "intentional", l.ContextMap()["error"].(string))
+	assert.Nil(t, l.ContextMap()["error_code"])
+
+	r = httptest.NewRequest("GET", "/httperror", nil)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res = w.Result()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	l = logs.All()[1]
+	assert.Equal(t, "bad input", l.ContextMap()["error"].(string))
+	assert.Equal(t, int64(http.StatusBadRequest), l.ContextMap()["error_code"].(int64))
+	assert.Equal(t, "internal", l.ContextMap()["error_internal"].(string))
+
+	r = httptest.NewRequest("GET", "/wrapped", nil)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l = logs.All()[2]
+	assert.Equal(t, "bad input", l.ContextMap()["error"].(string))
+	assert.Equal(t, int64(http.StatusBadRequest), l.ContextMap()["error_code"].(int64))
+	assert.Equal(t, "wrapped: code=400, message=bad input", l.ContextMap()["error_wrapped"].(string))
+
+	// Not wrapped
+	assert.Nil(t, logs.All()[1].ContextMap()["error_wrapped"])
 }
 
 func TestLoggerWithCustomRequestIDHeader(t *testing.T) {
@@ -430,6 +555,79 @@ func TestDefaultLoggerWithFieldAdder(t *testing.T) {
 	assert.Equal(t, true, l.ContextMap()["b"].(bool))
 }
 
+func TestLoggerWithLevelFunc(t *testing.T) {
+	config := LoggerConfig{
+		LevelFunc: func(c echo.Context, status int, err error) zapcore.Level {
+			if status == http.StatusUnauthorized {
+				return zapcore.InfoLevel
+			}
+			return DefaultLevelFunc(c, status, err)
+		},
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/unauthorized", func(c echo.Context) error {
+		return c.NoContent(http.StatusUnauthorized)
+	})
+
+	e.GET("/teapot", func(c echo.Context) error {
+		return c.NoContent(http.StatusTeapot)
+	})
+
+	r := httptest.NewRequest("GET", "/unauthorized", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Equal(t, zapcore.InfoLevel, logs.All()[0].Level)
+
+	r = httptest.NewRequest("GET", "/teapot", nil)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res = w.Result()
+	assert.Equal(t, http.StatusTeapot, res.StatusCode)
+	assert.Equal(t, zapcore.WarnLevel, logs.All()[1].Level)
+}
+
+func TestLoggerWithSetLevel(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Logger(log)
+	e := createTestEcho(m)
+
+	e.GET("/suspicious", func(c echo.Context) error {
+		SetLevel(c, zapcore.WarnLevel)
+		return c.NoContent(http.StatusOK)
+	})
+
+	e.GET("/expected", func(c echo.Context) error {
+		SetLevel(c, zapcore.DebugLevel)
+		return c.NoContent(http.StatusNotFound)
+	})
+
+	r := httptest.NewRequest("GET", "/suspicious", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+
+	r = httptest.NewRequest("GET", "/expected", nil)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res = w.Result()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// Debug level is below the level of the test logger.
+	assert.Equal(t, 1, logs.Len())
+}
+
 func createTestEcho(middleware echo.MiddlewareFunc) *echo.Echo {
 	e := echo.New()
 	e.Debug = true
@@ -0,0 +1,83 @@
+package zap4echo
+
+import (
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Config is the configuration of Middleware.
+type Config struct {
+	// Configuration of logging.
+	//
+	// Fields that are common to all requests (request ID,
+	// trace context, schema etc.) are configured here.
+	Logger LoggerConfig
+
+	// Configuration of panic recovery.
+	//
+	// Only the stack trace options, the panic options (e.g. DumpRequest,
+	// PanicDedupWindow, PanicThreshold), LogAbortHandler, ProblemDetails,
+	// ProblemMapper, FieldAdder and ErrorHandler are used. CustomMsg is ignored,
+	// and Logger.CustomMsg is used instead.
+	//
+	// If Logger.ProblemDetails is true, panics are also responded
+	// with problem details, using Logger.ProblemMapper.
+	Recover RecoverConfig
+}
+
+// Middleware combines logging and panic recovery, and writes exactly
+// one log entry per request.
+//
+// If the handler panics, the entry is logged with error level and status 500,
+// and it additionally has the `panic` field set to true, the `error` field
+// holding the panic value, and the `stacktrace` field (if enabled).
+// Panics are always logged, regardless of Skipper and ErrorOnly.
+// Panics with http.ErrAbortHandler are not recovered unless
+// Recover.LogAbortHandler is true.
+func Middleware(log *zap.Logger, config Config) echo.MiddlewareFunc {
+	if config.Recover.StackTrace {
+		// Stack trace is printed with the `stacktrace` field.
+		config.Logger.OmitStackTrace = true
+	}
+
+	if config.Logger.ProblemDetails && !config.Recover.ProblemDetails {
+		config.Recover.ProblemDetails = true
+		config.Recover.ProblemMapper = config.Logger.ProblemMapper
+	}
+
+	l := newRequestLogger(log, config.Logger)
+	if config.Recover.Fields == nil {
+		config.Recover.Fields = l.fields
+	}
+	rec := newRecoverer(log, config.Recover)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := l.begin(c)
+			defer r.restore()
+			getState(c).recoverer = rec
+			body := rec.readBody(c)
+
+			herr, p := func() (herr error, p *recoveredPanic) {
+				defer func() {
+					if err := recover(); err != nil {
+						p = rec.recovered(c, err, body)
+					}
+				}()
+				return next(c), nil
+			}()
+			if herr != nil {
+				l.respond(c, herr)
+			}
+
+			fields := l.write(c, r, herr, p)
+
+			if p != nil {
+				rec.finish(c, p, fields)
+			}
+
+			// We already handled error with c.Error
+			return nil
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package zap4echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMiddleware(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Middleware(log, Config{})
+	e := createTestEcho(m)
+
+	e.GET("/hello", func(c echo.Context) error {
+		return c.String(http.StatusOK, "Hello!")
+	})
+
+	r := httptest.NewRequest("GET", "/hello", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	assert.Equal(t, 1, logs.Len())
+	l := logs.All()[0]
+	assert.Equal(t, zapcore.InfoLevel, l.Level)
+	assert.Equal(t, DefaultLoggerMsg, l.Message)
+	assert.Equal(t, int64(http.StatusOK), l.ContextMap()["status"].(int64))
+	assert.Nil(t, l.ContextMap()["panic"])
+}
+
+func TestMiddlewareWithPanic(t *testing.T) {
+	var handledErr error
+	config := Config{
+		Logger: LoggerConfig{
+			Skipper: func(c echo.Context) bool {
+				return true
+			},
+			LevelFunc: func(c echo.Context, status int, err error) zapcore.Level {
+				return zapcore.DebugLevel
+			},
+		},
+		Recover: RecoverConfig{
+			StackTrace: true,
+			FieldAdder: func(c echo.Context, err error) []zap.Field {
+				return []zap.Field{zap.String("hello", "world!")}
+			},
+			ErrorHandler: func(c echo.Context, err error) {
+				handledErr = err
+			},
+		},
+	}
+
+	log, logs := createTestZapLogger()
+	m := Middleware(log, config)
+	e := createTestEcho(m)
+
+	const oops = "Oops, I did it again, I played with your heart"
+
+	e.GET("/panic", func(c echo.Context) error {
+		if true {
+			panic(oops)
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	// Panics are logged regardless of Skipper and LevelFunc.
+	assert.Equal(t, 1, logs.Len())
+	l := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, l.Level)
+	assert.Equal(t, DefaultLoggerMsg, l.Message)
+	assert.Equal(t, int64(http.StatusInternalServerError), l.ContextMap()["status"].(int64))
+	assert.Equal(t, true, l.ContextMap()["panic"].(bool))
+	assert.Equal(t, oops, l.ContextMap()["error"].(string))
+	assert.Contains(t, l.ContextMap()["stacktrace"].(string), "zap4echo")
+	assert.Equal(t, "world!", l.ContextMap()["hello"].(string))
+	assert.Equal(t, "/panic", l.ContextMap()["path"].(string))
+	assert.Equal(t, "", l.Stack, "stack trace should only be printed with the stacktrace field")
+
+	assert.EqualError(t, handledErr, "panic: "+oops)
+}
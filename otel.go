@@ -0,0 +1,45 @@
+package zap4echo
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Returns the trace context of the OpenTelemetry span in ctx, or nil if there is none.
+func spanTrace(ctx context.Context) *traceContext {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return &traceContext{
+		traceID: sc.TraceID().String(),
+		spanID:  sc.SpanID().String(),
+		sampled: sc.IsSampled(),
+		state:   sc.TraceState().String(),
+	}
+}
+
+// Record the panic as an exception event on the OpenTelemetry span in ctx,
+// and set the status of the span to error. If stack is nil, it is captured.
+//
+// See: https://opentelemetry.io/docs/specs/semconv/exceptions/exceptions-spans/
+func recordPanic(ctx context.Context, value interface{}, stack []byte) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	if stack == nil {
+		stack = debug.Stack()
+	}
+	span.AddEvent("exception", trace.WithAttributes(
+		attribute.String("exception.type", fmt.Sprintf("%T", value)),
+		attribute.String("exception.message", fmt.Sprint(value)),
+		attribute.String("exception.stacktrace", string(stack)),
+	))
+	span.SetStatus(codes.Error, fmt.Sprint(value))
+}
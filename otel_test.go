@@ -0,0 +1,136 @@
+package zap4echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLoggerWithOTelSpan(t *testing.T) {
+	span := newTestSpan()
+
+	log, logs := createTestZapLogger()
+	e := echo.New()
+	e.Use(createTestTracingMiddleware(span), Logger(log))
+
+	e.GET("/", func(c echo.Context) error {
+		FromContext(c).Info("Handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	sc := span.SpanContext()
+
+	assert.Equal(t, 2, logs.Len())
+	for _, l := range logs.All() {
+		assert.Equal(t, sc.TraceID().String(), l.ContextMap()["trace_id"].(string))
+		assert.Equal(t, sc.SpanID().String(), l.ContextMap()["span_id"].(string))
+		assert.Equal(t, true, l.ContextMap()["trace_sampled"].(bool))
+	}
+}
+
+func TestRecoverWithOTelSpan(t *testing.T) {
+	span := newTestSpan()
+
+	log, logs := createTestZapLogger()
+	e := echo.New()
+	e.Use(createTestTracingMiddleware(span), Recover(log))
+
+	const oops = "Oops, I did it again, I played with your heart"
+
+	e.GET("/panic", func(c echo.Context) error {
+		if true {
+			panic(oops)
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	assert.Equal(t, codes.Error, span.statusCode)
+	assert.Equal(t, oops, span.statusDescription)
+	assert.Equal(t, 1, len(span.events))
+
+	event := span.events[0]
+	assert.Equal(t, "exception", event.name)
+	attrs := attribute.NewSet(event.attributes...)
+	typ, _ := attrs.Value("exception.type")
+	assert.Equal(t, "string", typ.AsString())
+	msg, _ := attrs.Value("exception.message")
+	assert.Equal(t, oops, msg.AsString())
+	stack, _ := attrs.Value("exception.stacktrace")
+	assert.Contains(t, stack.AsString(), "zap4echo.TestRecoverWithOTelSpan")
+
+	l := logs.All()[0]
+	assert.Equal(t, span.SpanContext().TraceID().String(), l.ContextMap()["trace_id"].(string))
+}
+
+// A recording span that keeps the events and the status set on it.
+type testSpan struct {
+	// Not implemented methods panic.
+	trace.Span
+
+	sc trace.SpanContext
+
+	mu                sync.Mutex
+	events            []testSpanEvent
+	statusCode        codes.Code
+	statusDescription string
+}
+
+type testSpanEvent struct {
+	name       string
+	attributes []attribute.KeyValue
+}
+
+func newTestSpan() *testSpan {
+	return &testSpan{
+		sc: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+			SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+			TraceFlags: trace.FlagsSampled,
+		}),
+	}
+}
+
+func (s *testSpan) SpanContext() trace.SpanContext { return s.sc }
+func (s *testSpan) IsRecording() bool              { return true }
+
+func (s *testSpan) AddEvent(name string, options ...trace.EventOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	config := trace.NewEventConfig(options...)
+	s.events = append(s.events, testSpanEvent{name: name, attributes: config.Attributes()})
+}
+
+func (s *testSpan) SetStatus(code codes.Code, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statusCode = code
+	s.statusDescription = description
+}
+
+func createTestTracingMiddleware(span trace.Span) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := trace.ContextWithSpan(c.Request().Context(), span)
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
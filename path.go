@@ -0,0 +1,117 @@
+package zap4echo
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+type pathLogger struct {
+	redactQueryParams []string
+	scrubPatterns     []*regexp.Regexp
+	queryAsObject     bool
+}
+
+func newPathLogger(config *LoggerConfig) *pathLogger {
+	if len(config.RedactQueryParams) == 0 && len(config.ScrubPathPatterns) == 0 && !config.LogQueryAsObject {
+		return nil
+	}
+	return &pathLogger{
+		redactQueryParams: config.RedactQueryParams,
+		scrubPatterns:     config.ScrubPathPatterns,
+		queryAsObject:     config.LogQueryAsObject,
+	}
+}
+
+// Returns the path to log, and the query to log separately if LogQueryAsObject is set.
+//
+// Use RequestURI instead of URL.Path.
+// See: https://github.com/golang/go/issues/2782
+func (l *pathLogger) path(requestURI string) (path string, query url.Values) {
+	if l == nil {
+		return requestURI, nil
+	}
+
+	path, rawQuery := requestURI, ""
+	if i := strings.IndexByte(requestURI, '?'); i >= 0 {
+		path, rawQuery = requestURI[:i], requestURI[i+1:]
+	}
+
+	if l.queryAsObject {
+		if rawQuery != "" {
+			// Error is ignored, as malformed pairs are simply skipped.
+			query, _ = url.ParseQuery(rawQuery)
+			redactValues(query, l.redactQueryParams)
+		}
+	} else if rawQuery != "" {
+		path += "?" + redactRawQuery(rawQuery, l.redactQueryParams)
+	}
+
+	for _, re := range l.scrubPatterns {
+		path = scrub(re, path)
+	}
+	return path, query
+}
+
+// Redact the values of parameters without altering the order of the query.
+// It is also used for form bodies, which have the same syntax.
+func redactRawQuery(rawQuery string, keys []string) string {
+	if len(keys) == 0 {
+		return rawQuery
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		rawKey := pair
+		if j := strings.IndexByte(pair, '='); j >= 0 {
+			rawKey = pair[:j]
+		}
+		key, err := url.QueryUnescape(rawKey)
+		if err != nil {
+			key = rawKey
+		}
+		for _, param := range keys {
+			if strings.EqualFold(key, param) {
+				pairs[i] = rawKey + "=" + redacted
+				break
+			}
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// Replace the matches of the pattern. If the pattern has
+// capturing groups, only the groups are replaced.
+func scrub(re *regexp.Regexp, s string) string {
+	if re.NumSubexp() == 0 {
+		return re.ReplaceAllLiteralString(s, redacted)
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(s, -1) {
+		for g := 1; g <= re.NumSubexp(); g++ {
+			start, end := m[2*g], m[2*g+1]
+			if start < last {
+				// Group did not match, or is nested in a replaced group.
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(redacted)
+			last = end
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+type queryObject url.Values
+
+func (q queryObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, vs := range q {
+		enc.AddString(k, strings.Join(vs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package zap4echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerWithPathRedaction(t *testing.T) {
+	config := LoggerConfig{
+		RedactQueryParams: []string{"access_token", "signature"},
+		ScrubPathPatterns: []*regexp.Regexp{
+			regexp.MustCompile(`^/reset/([^/?]+)`),
+			regexp.MustCompile(`\d{16}`),
+		},
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/reset/:token", func(c echo.Context) error {
+		FromContext(c).Info("Handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/reset/abcdef?b=1&access_token=secret&card=1234123412341234&Signature=x&a", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	const expected = "/reset/[REDACTED]?b=1&access_token=[REDACTED]&card=[REDACTED]&Signature=[REDACTED]&a"
+	assert.Equal(t, expected, logs.All()[0].ContextMap()["path"].(string))
+	assert.Equal(t, expected, logs.All()[1].ContextMap()["path"].(string))
+	assert.Nil(t, logs.All()[1].ContextMap()["query"])
+}
+
+func TestLoggerWithQueryAsObject(t *testing.T) {
+	config := LoggerConfig{
+		RedactQueryParams: []string{"access_token"},
+		LogQueryAsObject:  true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/search", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/search?q=zap&tag=a&tag=b&access_token=secret", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	l := logs.All()[0]
+	assert.Equal(t, "/search", l.ContextMap()["path"].(string))
+	assert.Equal(t, map[string]interface{}{
+		"q":            "zap",
+		"tag":          "a, b",
+		"access_token": "[REDACTED]",
+	}, l.ContextMap()["query"])
+
+	r = httptest.NewRequest("GET", "/search", nil)
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l = logs.All()[1]
+	assert.Equal(t, "/search", l.ContextMap()["path"].(string))
+	assert.Nil(t, l.ContextMap()["query"])
+}
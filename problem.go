@@ -0,0 +1,101 @@
+package zap4echo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem is a problem details object as defined in RFC 7807.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// PanicError is the error of a panic whose value is not an error.
+type PanicError struct {
+	Value interface{}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// DefaultProblemMapper maps an *echo.HTTPError to a problem with its code and
+// message, and any other error (including panics) to an internal server error.
+//
+// The detail of other errors is only given in debug mode of Echo,
+// so that internals are not leaked to the client.
+func DefaultProblemMapper(c echo.Context, err error) *Problem {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		p := &Problem{Status: he.Code}
+		if msg, ok := he.Message.(string); ok && msg != http.StatusText(he.Code) {
+			p.Detail = msg
+		}
+		return p
+	}
+
+	p := &Problem{Status: http.StatusInternalServerError}
+	if c.Echo().Debug {
+		p.Detail = err.Error()
+	}
+	return p
+}
+
+// Write err as an `application/problem+json` response.
+//
+// The problem is created with mapper, or with DefaultProblemMapper if mapper
+// is nil or returns nil. Empty fields of the problem are filled in: type with
+// `about:blank`, status with 500, title with the status text, and instance with
+// the request ID as `urn:request-id:<id>`. The problem returned by mapper
+// is not modified.
+func respondProblem(c echo.Context, err error, mapper func(c echo.Context, err error) *Problem) {
+	if c.Response().Committed {
+		return
+	}
+
+	var p *Problem
+	if mapper != nil {
+		p = mapper(c, err)
+	}
+	if p == nil {
+		p = DefaultProblemMapper(c, err)
+	}
+	// The mapper may return a shared problem, which must not be modified.
+	problem := *p
+	p = &problem
+
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		if requestID := RequestID(c); requestID != "" {
+			p.Instance = "urn:request-id:" + requestID
+		}
+	}
+
+	var werr error
+	if c.Request().Method == http.MethodHead {
+		werr = c.NoContent(p.Status)
+	} else {
+		c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+		werr = c.JSON(p.Status, p)
+	}
+	if werr != nil {
+		c.Logger().Error(werr)
+	}
+}
@@ -0,0 +1,140 @@
+package zap4echo
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) Problem {
+	assert.Equal(t, MIMEApplicationProblemJSON, w.Header().Get(echo.HeaderContentType))
+	var p Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	return p
+}
+
+func TestRecoverWithProblemDetails(t *testing.T) {
+	const requestID = "31337"
+	log, _ := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{ProblemDetails: true})
+	e := createTestEcho(m)
+	e.Debug = false
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("secret internals")
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	r.Header.Set(DefaultRequestIDHeader, requestID)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, Problem{
+		Type:     "about:blank",
+		Title:    "Internal Server Error",
+		Status:   http.StatusInternalServerError,
+		Instance: "urn:request-id:" + requestID,
+	}, p)
+}
+
+func TestLoggerWithProblemDetails(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, LoggerConfig{ProblemDetails: true})
+	e := createTestEcho(m)
+
+	e.GET("/missing", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "No such user")
+	})
+
+	r := httptest.NewRequest("GET", "/missing", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, "Not Found", p.Title)
+	assert.Equal(t, http.StatusNotFound, p.Status)
+	assert.Equal(t, "No such user", p.Detail)
+
+	l := logs.All()[0]
+	assert.Equal(t, int64(http.StatusNotFound), l.ContextMap()["status"].(int64))
+}
+
+func TestMiddlewareWithProblemMapper(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+
+	log, _ := createTestZapLogger()
+	m := Middleware(log, Config{
+		Logger: LoggerConfig{
+			ProblemDetails: true,
+			ProblemMapper: func(c echo.Context, err error) *Problem {
+				if errors.Is(err, errQuota) {
+					return &Problem{Type: "https://example.com/quota", Status: http.StatusTooManyRequests}
+				}
+				var pe *PanicError
+				if errors.As(err, &pe) && pe.Value == "teapot" {
+					return &Problem{Status: http.StatusTeapot, Title: "Teapot"}
+				}
+				return nil
+			},
+		},
+	})
+	e := createTestEcho(m)
+
+	e.GET("/quota", func(c echo.Context) error {
+		return errQuota
+	})
+	e.GET("/teapot", func(c echo.Context) error {
+		panic("teapot")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/quota", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	p := decodeProblem(t, w)
+	assert.Equal(t, "https://example.com/quota", p.Type)
+	assert.Equal(t, "Too Many Requests", p.Title)
+
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/teapot", nil))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	p = decodeProblem(t, w)
+	assert.Equal(t, "Teapot", p.Title)
+}
+
+func TestProblemMapperWithSharedProblem(t *testing.T) {
+	quota := &Problem{Type: "https://example.com/quota", Status: http.StatusTooManyRequests}
+
+	log, _ := createTestZapLogger()
+	m := LoggerWithConfig(log, LoggerConfig{
+		ProblemDetails: true,
+		ProblemMapper: func(c echo.Context, err error) *Problem {
+			return quota
+		},
+	})
+	e := createTestEcho(m)
+
+	e.GET("/", func(c echo.Context) error {
+		return errors.New("quota exceeded")
+	})
+
+	for _, requestID := range []string{"1", "2"} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(echo.HeaderXRequestID, requestID)
+		w := httptest.NewRecorder()
+		e.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		p := decodeProblem(t, w)
+		assert.Equal(t, "urn:request-id:"+requestID, p.Instance)
+	}
+
+	assert.Equal(t, &Problem{Type: "https://example.com/quota", Status: http.StatusTooManyRequests}, quota)
+}
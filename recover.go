@@ -1,8 +1,10 @@
 package zap4echo
 
 import (
-	"fmt"
-	"runtime"
+	"context"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
@@ -12,8 +14,9 @@ import (
 const DefaultRecoverMsg = "Recovered"
 
 var defaultRecoverConfig = RecoverConfig{
-	StackTrace:     false,
-	StackTraceSize: 4 << 10, // 4 KB
+	StackTrace:        false,
+	StackTraceSize:    4 << 10, // 4 KB
+	StackTraceMaxSize: 1 << 20, // 1 MB
 }
 
 type RecoverConfig struct {
@@ -24,16 +27,126 @@ type RecoverConfig struct {
 	// `stacktrace` field will be used to print stack trace.
 	StackTrace bool
 	// Size allocated on memory for stack trace.
+	// If the stack trace does not fit, it is truncated and
+	// the `stacktrace_truncated` field is added.
 	StackTraceSize int
+	// If true, the memory allocated for stack trace grows until the stack trace fits,
+	// up to StackTraceMaxSize. StackTraceSize is used as the initial size.
+	StackTraceAutoSize bool
+	// Upper limit of StackTraceAutoSize. Defaults to 1 MB.
+	StackTraceMaxSize int
 	// If stack trace is enabled, this is to print stack traces of all goroutines.
 	PrintStackTraceOfAllGoroutines bool
+	// If set, stack traces of all goroutines are written to a new file in this
+	// directory, and only the path of the file is logged with the `stacktrace_file` field.
+	// The memory allocated for the dump grows as with StackTraceAutoSize.
+	StackTraceDumpDir string
+
+	// If stack trace is enabled, this is to print the stack trace as an array of
+	// `{function, file, line}` objects instead of a string, and to add the
+	// `panic_location` field naming the first frame of the application.
+	// PrintStackTraceOfAllGoroutines and StackTraceSize are ignored.
+	StructuredStackTrace bool
+	// Drop the frames of the Go runtime from the structured stack trace.
+	OmitRuntimeFrames bool
+	// Drop the frames of zap4echo and Echo from the structured stack trace.
+	OmitInternalFrames bool
+	// Maximum number of frames in the structured stack trace. 0 means no limit.
+	StackTraceDepth int
+
+	// If true, a redacted snapshot of the request is logged with the `request_dump`
+	// field (method, path, query, params, headers and body), and a curl command
+	// that reproduces the request is logged with the `curl` field.
+	//
+	// The body is read before the handler, up to DumpRequestBodySize. Only bodies
+	// of DefaultBodyContentTypes are read. Headers, query parameters and body keys
+	// are redacted with the rules of Fields (RedactHeaders, RedactQueryParams,
+	// ScrubPathPatterns and RedactBodyKeys).
+	//
+	// Content-Length, Accept-Encoding and hop-by-hop headers are not copied to the
+	// curl command, and a truncated body is left out of it.
+	DumpRequest bool
+	// Maximum size of the dumped request body. Defaults to 4 KB.
+	DumpRequestBodySize int
+
+	// If set, a panic with the same `panic_fingerprint` as a panic within this
+	// window is logged without the stack trace, and with the `panic_repeated` field.
+	// At the end of the window, the number of occurrences is logged.
+	//
+	// The fingerprint is computed from the type of the panic value
+	// and the top frames of the application.
+	PanicDedupWindow time.Duration
+
+	// If set, a summary is logged with fatal level and OnThreshold is called
+	// when more than PanicThreshold panics happen within PanicThresholdWindow.
+	//
+	// A process that keeps recovering from a corrupted state may be worse than a restart.
+	PanicThreshold int
+	// Defaults to 1 minute.
+	PanicThresholdWindow time.Duration
+	// Called when PanicThreshold is exceeded.
+	// Defaults to flushing the logger and exiting with status 1.
+	OnThreshold func()
 
 	// Custom header name for request ID
 	CustomRequestIDHeader string
 
+	// If set, a request ID is generated with this function if the request has none.
+	// It is set to the response header, and can be retrieved with RequestID.
+	//
+	// Built-in generators are GenerateUUIDv4, GenerateUUIDv7 and GenerateULID.
+	RequestIDGenerator func() string
+
+	// Trace context headers to parse. If set, `trace_id`, `span_id`,
+	// `trace_sampled` and `trace_state` (W3C only) fields are logged.
+	TraceFormat TraceFormat
+	// If true, a new trace ID is started when the request has no trace context.
+	GenerateTraceID bool
+
+	// Keys of the logged fields. Presets are SchemaECS, SchemaOTel and SchemaGCP.
+	// Defaults to the keys documented in README.
+	Schema Schema
+
+	// Builder of the fields describing the request. Share the FieldBuilder of
+	// the logger to log the same request context (including the fields of
+	// LoggerConfig.FieldAdder) with the same Omit* options, schema and redaction rules.
+	//
+	// If set, CustomRequestIDHeader, RequestIDGenerator, TraceFormat,
+	// GenerateTraceID and Schema of RecoverConfig are ignored, and the
+	// LoggerConfig the FieldBuilder was created with is used instead.
+	Fields *FieldBuilder
+
+	// By default, panics with http.ErrAbortHandler are not recovered, so that
+	// net/http can abort the response as intended.
+	//
+	// If true, they are recovered and logged with debug level instead,
+	// and neither an error response is written nor ErrorHandler is called.
+	LogAbortHandler bool
+
+	// If true, an HTML page with the panic value, the stack trace, the source
+	// code around the frames of the application, and the headers and params of
+	// the request is rendered instead of the error response. It is meant for
+	// local development.
+	//
+	// To prevent leaking it in production, the page is only rendered in debug
+	// mode of Echo (e.Debug), and only to browsers (requests accepting text/html).
+	DevErrorPage bool
+
+	// If true, panics are responded with `application/problem+json` (RFC 7807)
+	// instead of the HTTP error handler of Echo.
+	ProblemDetails bool
+	// Maps panics to problems. Panic values that are not errors are
+	// given as *PanicError. Defaults to DefaultProblemMapper.
+	ProblemMapper func(c echo.Context, err error) *Problem
+
 	// A function for adding custom fields depending on the context.
 	FieldAdder func(c echo.Context, err error) []zap.Field
 
+	// If set, panics are reported with the stack trace, the request ID
+	// and the logged fields. See NewAsyncReporter for reporting in the
+	// background, and FileReporter and MemoryReporter.
+	Reporter Reporter
+
 	// The panic was happened, and it was handled and logged gracefully.
 	// What's next?
 	//
@@ -46,6 +159,51 @@ func Recover(log *zap.Logger) echo.MiddlewareFunc {
 }
 
 func RecoverWithConfig(log *zap.Logger, config RecoverConfig) echo.MiddlewareFunc {
+	r := newRecoverer(log, config)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r.fields.begin(c)
+			getState(c).recoverer = r
+			body := r.readBody(c)
+
+			defer func() {
+				if err := recover(); err != nil {
+					p := r.recovered(c, err, body)
+					fields := r.logFields(c, p)
+
+					msg := func() string {
+						if config.CustomMsg == "" {
+							return DefaultRecoverMsg
+						} else {
+							return config.CustomMsg
+						}
+					}()
+					if p.aborted {
+						r.log.Debug(msg, fields...)
+					} else {
+						r.log.Error(msg, fields...)
+					}
+
+					r.finish(c, p, fields)
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+type recoverer struct {
+	log     *zap.Logger
+	config  RecoverConfig
+	fields  *FieldBuilder
+	stack   *stackFilter
+	dump    *requestDumper
+	dedup   *panicDedup
+	breaker *panicBreaker
+}
+
+func newRecoverer(log *zap.Logger, config RecoverConfig) *recoverer {
 	if config.StackTrace {
 		// Disable printing of stacktrace. We will manually print it.
 		log = log.WithOptions(zap.AddStacktrace(zap.FatalLevel + 1))
@@ -53,77 +211,257 @@ func RecoverWithConfig(log *zap.Logger, config RecoverConfig) echo.MiddlewareFun
 		if config.StackTraceSize == 0 {
 			config.StackTraceSize = defaultRecoverConfig.StackTraceSize
 		}
+		if config.StackTraceMaxSize == 0 {
+			config.StackTraceMaxSize = defaultRecoverConfig.StackTraceMaxSize
+		}
 	}
+	if config.PanicThreshold > 0 {
+		if config.PanicThresholdWindow == 0 {
+			config.PanicThresholdWindow = defaultPanicThresholdWindow
+		}
+		if config.OnThreshold == nil {
+			config.OnThreshold = func() {
+				_ = log.Sync()
+				os.Exit(1)
+			}
+		}
+	}
+	fields := config.Fields
+	if fields == nil {
+		fields = NewFieldBuilder(LoggerConfig{
+			CustomRequestIDHeader: config.CustomRequestIDHeader,
+			RequestIDGenerator:    config.RequestIDGenerator,
+			TraceFormat:           config.TraceFormat,
+			GenerateTraceID:       config.GenerateTraceID,
+			Schema:                config.Schema,
+		})
+	}
+	r := &recoverer{
+		log:    log,
+		config: config,
+		fields: fields,
+		stack: &stackFilter{
+			omitRuntime:  config.OmitRuntimeFrames,
+			omitInternal: config.OmitInternalFrames,
+			depth:        config.StackTraceDepth,
+		},
+	}
+	if config.DumpRequest {
+		if config.DumpRequestBodySize <= 0 {
+			config.DumpRequestBodySize = defaultBodyMaxSize
+		}
+		r.dump = newRequestDumper(&fields.config, config.DumpRequestBodySize)
+	}
+	if config.PanicDedupWindow > 0 {
+		r.dedup = newPanicDedup(log, config.PanicDedupWindow)
+	}
+	if config.PanicThreshold > 0 {
+		r.breaker = newPanicBreaker(log, config.PanicThreshold, config.PanicThresholdWindow, config.OnThreshold)
+	}
+	return r
+}
 
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			defer func() {
-				if err := recover(); err != nil {
-					e := func() error {
-						if e, ok := err.(error); ok {
-							return e
-						} else {
-							return fmt.Errorf("panic: %v", err)
-						}
-					}()
+type recoveredPanic struct {
+	value  interface{}
+	err    error
+	stack  []byte
+	frames []StackFrame
 
-					c.Error(e)
+	fingerprint string
 
-					req := c.Request()
-					resp := c.Response()
+	// The handler has panicked with http.ErrAbortHandler.
+	aborted bool
 
-					fields := make([]zap.Field, 0, 6)
-					fields = append(fields, []zapcore.Field{
-						zap.Any("error", err),
-						zap.String("method", req.Method),
+	// Fields describing the panic, subject to Schema.
+	fields []zapcore.Field
+	// Fields from FieldAdder.
+	customFields []zapcore.Field
+}
 
-						// Use RequestURI instead of URL.Path.
-						// See: https://github.com/golang/go/issues/2782
-						zap.String("path", req.RequestURI),
+// Read the beginning of the request body to dump, if DumpRequest is set.
+// Called before the handler.
+func (r *recoverer) readBody(c echo.Context) *dumpedBody {
+	if r.dump == nil {
+		return nil
+	}
+	return r.dump.readBody(c)
+}
 
-						zap.String("client_ip", c.RealIP()),
-					}...)
+// Handle the recovered panic value. This must be called in the deferred
+// function that recovers, so that the stack trace can be captured.
+// body is the request body read with readBody.
+func (r *recoverer) recovered(c echo.Context, value interface{}, body *dumpedBody) *recoveredPanic {
+	config := &r.config
 
-					if config.StackTrace {
-						stack := make([]byte, config.StackTraceSize)
-						stackLen := runtime.Stack(stack, config.PrintStackTraceOfAllGoroutines)
-						fields = append(fields, zap.ByteString("stacktrace", stack[:stackLen]))
-					}
+	if value == http.ErrAbortHandler {
+		if !config.LogAbortHandler {
+			panic(value)
+		}
+		return &recoveredPanic{
+			value:   value,
+			err:     http.ErrAbortHandler,
+			aborted: true,
+			fields:  []zapcore.Field{zap.Any("error", value)},
+		}
+	}
 
-					requestIDHeader := func() string {
-						if config.CustomRequestIDHeader != "" {
-							return config.CustomRequestIDHeader
-						} else {
-							return DefaultRequestIDHeader
-						}
-					}()
-					requestID := req.Header.Get(requestIDHeader)
-					if requestID == "" {
-						requestID = resp.Header().Get(requestIDHeader)
-					}
-					if requestID != "" {
-						fields = append(fields, zap.String("request_id", requestID))
-					}
+	p := newRecoveredPanic(value)
+	// Start from the deferred function that has recovered.
+	repeated := r.capture(p, 1)
 
-					if config.FieldAdder != nil {
-						fields = append(fields, config.FieldAdder(c, e)...)
-					}
+	if resp := c.Response(); c.Request().Context().Err() == context.Canceled {
+		// The client is gone. There is no one to read the error response.
+		p.fields = append(p.fields, zap.Bool("client_disconnected", true))
+		if !resp.Committed {
+			resp.Status = http.StatusInternalServerError
+		}
+	} else if !resp.Committed {
+		// Writing the error response after the response has been
+		// committed would result in a "superfluous WriteHeader" warning.
+		if config.DevErrorPage && wantsDevPage(c) {
+			r.renderDevPage(c, p)
+		} else {
+			r.respond(c, p.err)
+		}
+	}
 
-					msg := func() string {
-						if config.CustomMsg == "" {
-							return DefaultRecoverMsg
-						} else {
-							return config.CustomMsg
-						}
-					}()
-					log.Error(msg, fields...)
+	if r.dump != nil && !repeated {
+		p.fields = append(p.fields, r.dump.fields(c, body)...)
+	}
 
-					if config.ErrorHandler != nil {
-						config.ErrorHandler(c, e)
-					}
-				}
-			}()
-			return next(c)
+	recordPanic(c.Request().Context(), p.value, p.stack)
+
+	if config.FieldAdder != nil {
+		p.customFields = config.FieldAdder(c, p.err)
+	}
+	return p
+}
+
+func newRecoveredPanic(value interface{}) *recoveredPanic {
+	p := &recoveredPanic{
+		value: value,
+		err: func() error {
+			if e, ok := value.(error); ok {
+				return e
+			} else {
+				return &PanicError{Value: value}
+			}
+		}(),
+	}
+	p.fields = append(p.fields, zap.Any("error", value))
+	return p
+}
+
+// Capture the stack trace and the fingerprint of the panic.
+// skip is the number of frames to skip, with 0 identifying the caller of capture.
+//
+// Reports whether the panic is repeated within PanicDedupWindow,
+// in which case the stack trace is not logged.
+func (r *recoverer) capture(p *recoveredPanic, skip int) (repeated bool) {
+	config := &r.config
+
+	p.frames = captureStack(skip + 1)
+	p.fingerprint = panicFingerprint(p.value, p.frames)
+	p.fields = append(p.fields, zap.String("panic_fingerprint", p.fingerprint))
+
+	repeated = r.dedup != nil && r.dedup.seen(p.fingerprint)
+	if repeated {
+		// Already logged with the stack trace within the window.
+		p.fields = append(p.fields, zap.Bool("panic_repeated", true))
+	} else if config.StackTrace && config.StructuredStackTrace {
+		p.fields = append(p.fields, zap.Array("stacktrace", stackFrames(r.stack.filter(p.frames))))
+		if location, ok := panicLocation(p.frames); ok {
+			p.fields = append(p.fields, zap.Stringer("panic_location", location))
+		}
+	} else if config.StackTrace {
+		// The file gets the full dump.
+		toFile := config.PrintStackTraceOfAllGoroutines && config.StackTraceDumpDir != ""
+		stack, truncated := captureRawStack(config.StackTraceSize, config.StackTraceMaxSize,
+			config.PrintStackTraceOfAllGoroutines, config.StackTraceAutoSize || toFile)
+		p.stack = stack
+
+		dumped := false
+		if toFile {
+			path, err := dumpStack(config.StackTraceDumpDir, stack)
+			if err == nil {
+				p.fields = append(p.fields, zap.String("stacktrace_file", path))
+				dumped = true
+			} else {
+				// Fall back to logging the stack trace.
+				p.fields = append(p.fields, zap.NamedError("stacktrace_file_error", err))
+			}
+		}
+		if !dumped {
+			p.fields = append(p.fields, zap.ByteString("stacktrace", stack))
+		}
+		if truncated {
+			p.fields = append(p.fields, zap.Bool("stacktrace_truncated", true))
+		}
+	}
+	return repeated
+}
+
+// Fields of the log entry of the panic.
+func (r *recoverer) logFields(c echo.Context, p *recoveredPanic) []zapcore.Field {
+	fields := r.fields.requestFields(c)
+	fields = append(fields, r.fields.latency(c))
+	fields = append(fields, p.fields...)
+	fields = r.fields.config.Schema.apply(fields)
+	fields = r.fields.customFields(c, fields)
+	return append(fields, p.customFields...)
+}
+
+func (r *recoverer) respond(c echo.Context, err error) {
+	if r.config.ProblemDetails {
+		respondProblem(c, err, r.config.ProblemMapper)
+	} else {
+		c.Error(err)
+	}
+}
+
+// Called after the panic is logged. fields are the fields of
+// the log entry, or nil if the entry was not logged.
+func (r *recoverer) finish(c echo.Context, p *recoveredPanic, fields []zapcore.Field) {
+	if p.aborted {
+		return
+	}
+	if r.config.Reporter != nil {
+		req := c.Request()
+		path, _ := r.fields.paths.path(req.RequestURI)
+		event := p.event()
+		event.RequestID = RequestID(c)
+		event.Method = req.Method
+		event.Path = path
+		event.Route = c.Path()
+		if fields != nil {
+			event.Fields = fieldsMap(fields)
 		}
+		r.report(req.Context(), event)
+	}
+	if r.config.ErrorHandler != nil {
+		r.config.ErrorHandler(c, p.err)
+	}
+	if r.breaker != nil {
+		r.breaker.record()
+	}
+}
+
+func (p *recoveredPanic) event() PanicEvent {
+	return PanicEvent{
+		Time:        time.Now(),
+		Value:       p.value,
+		Err:         p.err,
+		Message:     p.err.Error(),
+		Fingerprint: p.fingerprint,
+		Stack:       p.frames,
+	}
+}
+
+func (r *recoverer) report(ctx context.Context, event PanicEvent) {
+	if err := r.config.Reporter.Report(ctx, event); err != nil {
+		r.log.Warn("Failed to report panic",
+			zap.Error(err),
+			zap.String("panic_fingerprint", event.Fingerprint),
+		)
 	}
 }
@@ -1,9 +1,13 @@
 package zap4echo
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
 	"sync"
 	"testing"
 
@@ -11,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestDefaultRecover(t *testing.T) {
@@ -142,6 +147,87 @@ func TestRecoverWithStackTraceSize(t *testing.T) {
 
 	assert.Equal(t, oops, l.ContextMap()["error"].(string))
 	assert.Equal(t, 10, len(stacktrace))
+	assert.Equal(t, true, l.ContextMap()["stacktrace_truncated"].(bool))
+}
+
+func TestRecoverWithStackTraceAutoSize(t *testing.T) {
+	config := RecoverConfig{
+		StackTrace:         true,
+		StackTraceSize:     10,
+		StackTraceAutoSize: true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		if true {
+			panic("oops")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	stacktrace := l.ContextMap()["stacktrace"].(string)
+
+	assert.Greater(t, len(stacktrace), 10)
+	assert.Contains(t, stacktrace, "TestRecoverWithStackTraceAutoSize")
+	assert.Nil(t, l.ContextMap()["stacktrace_truncated"])
+
+	// Limited by StackTraceMaxSize
+	config.StackTraceMaxSize = 20
+	log, logs = createTestZapLogger()
+	e = createTestEcho(RecoverWithConfig(log, config))
+	e.GET("/panic", func(c echo.Context) error {
+		panic("oops")
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	l = logs.All()[0]
+	assert.Equal(t, 20, len(l.ContextMap()["stacktrace"].(string)))
+	assert.Equal(t, true, l.ContextMap()["stacktrace_truncated"].(bool))
+}
+
+func TestRecoverWithStackTraceDumpDir(t *testing.T) {
+	dir := t.TempDir()
+	config := RecoverConfig{
+		StackTrace:                     true,
+		StackTraceSize:                 64, // The file gets the full dump regardless.
+		PrintStackTraceOfAllGoroutines: true,
+		StackTraceDumpDir:              dir,
+	}
+
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		if true {
+			panic("oops")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Nil(t, l.ContextMap()["stacktrace"])
+	assert.Nil(t, l.ContextMap()["stacktrace_truncated"])
+
+	path := l.ContextMap()["stacktrace_file"].(string)
+	assert.Equal(t, dir, filepath.Dir(path))
+
+	dump, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Greater(t, len(dump), 64)
+	assert.Contains(t, string(dump), "TestRecoverWithStackTraceDumpDir")
 }
 
 func TestRecoverWithCustomRequestIDHeader(t *testing.T) {
@@ -288,3 +374,204 @@ func TestRecoverWithErrorHandler(t *testing.T) {
 	assert.Equal(t, oops, l.ContextMap()["error"].(string))
 	wg.Wait()
 }
+
+func TestRecoverWithStructuredStackTrace(t *testing.T) {
+	config := RecoverConfig{
+		StackTrace:           true,
+		StructuredStackTrace: true,
+		OmitRuntimeFrames:    true,
+		OmitInternalFrames:   true,
+		StackTraceDepth:      2,
+	}
+
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		if true {
+			panic("oops")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	l := logs.All()[0]
+	stacktrace := l.ContextMap()["stacktrace"].([]interface{})
+	assert.Equal(t, 2, len(stacktrace))
+
+	frame := stacktrace[0].(map[string]interface{})
+	assert.Equal(t, "github.com/karagenc/zap4echo.TestRecoverWithStructuredStackTrace.func1", frame["function"])
+	assert.Contains(t, frame["file"], "recover_test.go")
+	assert.NotZero(t, frame["line"])
+
+	for _, f := range stacktrace {
+		function := f.(map[string]interface{})["function"].(string)
+		assert.NotContains(t, function, "runtime.")
+		assert.NotContains(t, function, "github.com/labstack/echo")
+	}
+
+	assert.Contains(t, l.ContextMap()["panic_location"].(string), "zap4echo.TestRecoverWithStructuredStackTrace.func1 (")
+	assert.Contains(t, l.ContextMap()["panic_location"].(string), "recover_test.go:")
+}
+
+func TestRecoverWithStructuredStackTraceWithoutFilters(t *testing.T) {
+	config := RecoverConfig{
+		StackTrace:           true,
+		StructuredStackTrace: true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		var m map[string]int
+		m["nil map"]++
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	stacktrace := l.ContextMap()["stacktrace"].([]interface{})
+
+	var functions []string
+	for _, f := range stacktrace {
+		functions = append(functions, f.(map[string]interface{})["function"].(string))
+	}
+	assert.Contains(t, functions, "runtime.gopanic")
+	assert.Contains(t, functions, "github.com/labstack/echo/v4.(*Echo).ServeHTTP")
+	assert.Contains(t, l.ContextMap()["panic_location"].(string), "TestRecoverWithStructuredStackTraceWithoutFilters.func1")
+}
+
+func TestRecoverWithPanicInStdlib(t *testing.T) {
+	config := RecoverConfig{
+		StackTrace:           true,
+		StructuredStackTrace: true,
+	}
+
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		regexp.MustCompile("(")
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	stacktrace := l.ContextMap()["stacktrace"].([]interface{})
+
+	var functions []string
+	for _, f := range stacktrace {
+		functions = append(functions, f.(map[string]interface{})["function"].(string))
+	}
+	assert.Contains(t, functions, "regexp.MustCompile")
+	assert.Contains(t, l.ContextMap()["panic_location"].(string), "TestRecoverWithPanicInStdlib.func1 (")
+}
+
+func TestRecoverWithErrAbortHandler(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Recover(log)
+	e := createTestEcho(m)
+
+	e.GET("/abort", func(c echo.Context) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	r := httptest.NewRequest("GET", "/abort", nil)
+	w := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		e.ServeHTTP(w, r)
+	})
+	assert.Equal(t, 0, logs.Len())
+}
+
+func TestRecoverWithLogAbortHandler(t *testing.T) {
+	handled := false
+	config := RecoverConfig{
+		StackTrace:      true,
+		LogAbortHandler: true,
+		ErrorHandler: func(c echo.Context, err error) {
+			handled = true
+		},
+	}
+
+	observed, logs := observer.New(zap.DebugLevel)
+	log := zap.New(observed)
+	m := RecoverWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/abort", func(c echo.Context) error {
+		panic(http.ErrAbortHandler)
+	})
+
+	r := httptest.NewRequest("GET", "/abort", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, 1, logs.Len())
+	l := logs.All()[0]
+	assert.Equal(t, zapcore.DebugLevel, l.Level)
+	assert.Nil(t, l.ContextMap()["stacktrace"])
+	assert.Equal(t, 0, w.Body.Len(), "no error response should be written")
+	assert.Equal(t, false, handled)
+}
+
+func TestRecoverWithCommittedResponse(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Recover(log)
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		c.String(http.StatusOK, "Hello!")
+		panic("oops")
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Hello!", w.Body.String())
+
+	l := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, l.Level)
+	assert.Equal(t, "oops", l.ContextMap()["error"].(string))
+}
+
+func TestRecoverWithClientDisconnected(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := Middleware(log, Config{})
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("oops")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := httptest.NewRequest("GET", "/panic", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, 0, w.Body.Len(), "no error response should be written")
+
+	l := logs.All()[0]
+	assert.Equal(t, zapcore.ErrorLevel, l.Level)
+	assert.Equal(t, int64(http.StatusInternalServerError), l.ContextMap()["status"].(int64))
+	assert.Equal(t, true, l.ContextMap()["client_disconnected"].(bool))
+}
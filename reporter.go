@@ -0,0 +1,301 @@
+package zap4echo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// PanicEvent describes a recovered panic, for reporting it to an error tracker.
+type PanicEvent struct {
+	Time time.Time `json:"time"`
+
+	// The value given to panic.
+	Value interface{} `json:"-"`
+	// The error of the panic. If the panic value is not an error, it is a *PanicError.
+	Err error `json:"-"`
+	// Message of Err.
+	Message string `json:"message"`
+
+	// See the `panic_fingerprint` field.
+	Fingerprint string `json:"fingerprint"`
+	// Stack trace of the goroutine that has panicked.
+	Stack []StackFrame `json:"stack"`
+
+	RequestID string `json:"request_id,omitempty"`
+	Method    string `json:"method,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Route     string `json:"route,omitempty"`
+	// The panic happened in a goroutine started with Go or SafeGo.
+	Goroutine bool `json:"goroutine,omitempty"`
+
+	// Fields of the log entry of the panic.
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+func fieldsMap(fields []zapcore.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// Reporter reports panics, e.g. to an error tracker.
+//
+// Report is called in the goroutine that has panicked, after the panic is
+// logged. Wrap reporters that are slow (e.g. that send the event over the
+// network) with NewAsyncReporter, so that the response is not delayed.
+type Reporter interface {
+	Report(ctx context.Context, event PanicEvent) error
+}
+
+var (
+	ErrReportQueueFull = errors.New("zap4echo: report queue is full")
+	ErrReporterClosed  = errors.New("zap4echo: reporter is closed")
+)
+
+type AsyncReporterConfig struct {
+	// Maximum number of events waiting to be reported.
+	// When the queue is full, events are dropped. Defaults to 100.
+	QueueSize int
+	// Number of retries after a failed report. Defaults to 3.
+	// Set to NoRetries (or any negative value) to disable retries.
+	MaxRetries int
+	// Delay before the first retry. It is doubled after each retry.
+	// Defaults to 1 second.
+	RetryDelay time.Duration
+	// Timeout of each attempt. 0 means no timeout.
+	Timeout time.Duration
+}
+
+// Value of AsyncReporterConfig.MaxRetries that disables retries.
+const NoRetries = -1
+
+var defaultAsyncReporterConfig = AsyncReporterConfig{
+	QueueSize:  100,
+	MaxRetries: 3,
+	RetryDelay: time.Second,
+}
+
+// AsyncReporter reports panics in the background with a bounded queue,
+// and retries failed reports.
+type AsyncReporter struct {
+	// Accessed atomically. They are first to be 64-bit aligned on 32-bit platforms.
+	dropped uint64
+	failed  uint64
+
+	reporter Reporter
+	config   AsyncReporterConfig
+
+	queue chan asyncReport
+	done  chan struct{}
+	// Closed when Close gives up waiting.
+	abort chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+type asyncReport struct {
+	ctx   context.Context
+	event PanicEvent
+}
+
+// NewAsyncReporter creates an AsyncReporter that reports the events with reporter.
+// Close it to report the remaining events.
+//
+// Zero or negative values of QueueSize and RetryDelay, and negative
+// values of Timeout are replaced with the defaults.
+func NewAsyncReporter(reporter Reporter, config AsyncReporterConfig) *AsyncReporter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultAsyncReporterConfig.QueueSize
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaultAsyncReporterConfig.MaxRetries
+	} else if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaultAsyncReporterConfig.RetryDelay
+	}
+	if config.Timeout < 0 {
+		config.Timeout = 0
+	}
+
+	a := &AsyncReporter{
+		reporter: reporter,
+		config:   config,
+		queue:    make(chan asyncReport, config.QueueSize),
+		done:     make(chan struct{}),
+		abort:    make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Report queues the event. If the queue is full, the event is
+// dropped and ErrReportQueueFull is returned.
+func (a *AsyncReporter) Report(ctx context.Context, event PanicEvent) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return ErrReporterClosed
+	}
+
+	// The event is reported after the request is done.
+	report := asyncReport{ctx: withoutCancel{ctx}, event: event}
+	select {
+	case a.queue <- report:
+		return nil
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+		return ErrReportQueueFull
+	}
+}
+
+// Dropped returns the number of events dropped because the queue was full.
+func (a *AsyncReporter) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Failed returns the number of events that could not be reported after
+// all retries, or before the deadline of Close.
+func (a *AsyncReporter) Failed() uint64 {
+	return atomic.LoadUint64(&a.failed)
+}
+
+// Close stops accepting events, and waits until the queued events are reported.
+//
+// If ctx is done first, the current attempt is canceled, the remaining
+// events are given up (and counted by Failed), and the error of ctx is returned.
+func (a *AsyncReporter) Close(ctx context.Context) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrReporterClosed
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		close(a.abort)
+		return ctx.Err()
+	}
+}
+
+func (a *AsyncReporter) run() {
+	defer close(a.done)
+	for report := range a.queue {
+		if a.aborted() || !a.report(report) {
+			atomic.AddUint64(&a.failed, 1)
+		}
+	}
+}
+
+func (a *AsyncReporter) aborted() bool {
+	select {
+	case <-a.abort:
+		return true
+	default:
+		return false
+	}
+}
+
+func (a *AsyncReporter) report(report asyncReport) bool {
+	delay := a.config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		if a.attempt(report) == nil {
+			return true
+		}
+		if attempt >= a.config.MaxRetries {
+			return false
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-a.abort:
+			return false
+		}
+		delay *= 2
+	}
+}
+
+func (a *AsyncReporter) attempt(report asyncReport) error {
+	ctx, cancel := context.WithCancel(report.ctx)
+	if a.config.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(report.ctx, a.config.Timeout)
+	}
+	defer cancel()
+
+	// Cancel the attempt if Close gives up waiting.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-a.abort:
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return a.reporter.Report(ctx, report.event)
+}
+
+// FileReporter writes the events to a file as JSON lines.
+type FileReporter struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewFileReporter creates a FileReporter that appends to the file at path.
+func NewFileReporter(path string) (*FileReporter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileReporter{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+func (r *FileReporter) Report(ctx context.Context, event PanicEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enc.Encode(event)
+}
+
+func (r *FileReporter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// MemoryReporter keeps the events in memory. It is useful for tests.
+type MemoryReporter struct {
+	mu     sync.Mutex
+	events []PanicEvent
+}
+
+func (r *MemoryReporter) Report(ctx context.Context, event PanicEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// Events returns the reported events.
+func (r *MemoryReporter) Events() []PanicEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]PanicEvent(nil), r.events...)
+}
@@ -0,0 +1,263 @@
+package zap4echo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRecoverWithReporter(t *testing.T) {
+	const requestID = "31337"
+	reporter := &MemoryReporter{}
+
+	log, _ := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		Reporter: reporter,
+	})
+	e := createTestEcho(m)
+
+	e.GET("/panic/:id", func(c echo.Context) error {
+		panic("oops")
+	})
+
+	r := httptest.NewRequest("GET", "/panic/42", nil)
+	r.Header.Set(DefaultRequestIDHeader, requestID)
+	e.ServeHTTP(httptest.NewRecorder(), r)
+
+	events := reporter.Events()
+	assert.Equal(t, 1, len(events))
+	event := events[0]
+	assert.Equal(t, "oops", event.Value)
+	assert.EqualError(t, event.Err, "panic: oops")
+	assert.Equal(t, "panic: oops", event.Message)
+	assert.NotEmpty(t, event.Fingerprint)
+	assert.NotEmpty(t, event.Stack)
+	assert.Equal(t, requestID, event.RequestID)
+	assert.Equal(t, "GET", event.Method)
+	assert.Equal(t, "/panic/42", event.Path)
+	assert.Equal(t, "/panic/:id", event.Route)
+	assert.Equal(t, "oops", event.Fields["error"])
+	assert.Equal(t, event.Fingerprint, event.Fields["panic_fingerprint"])
+}
+
+func TestGoWithReporter(t *testing.T) {
+	reporter := &MemoryReporter{}
+
+	log, _ := createTestZapLogger()
+	SafeGoWithConfig(log, RecoverConfig{Reporter: reporter}, func() {
+		panic("oops")
+	})
+
+	assert.Eventually(t, func() bool { return len(reporter.Events()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, true, reporter.Events()[0].Goroutine)
+}
+
+// Fails the first `failures` reports.
+type flakyReporter struct {
+	MemoryReporter
+	mu       sync.Mutex
+	failures int
+	block    chan struct{}
+}
+
+func (r *flakyReporter) Report(ctx context.Context, event PanicEvent) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	if r.failures > 0 {
+		r.failures--
+		r.mu.Unlock()
+		return errors.New("unavailable")
+	}
+	r.mu.Unlock()
+	return r.MemoryReporter.Report(ctx, event)
+}
+
+func TestAsyncReporterRetries(t *testing.T) {
+	flaky := &flakyReporter{failures: 2}
+	a := NewAsyncReporter(flaky, AsyncReporterConfig{
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, a.Report(ctx, PanicEvent{Message: "first"}))
+	cancel()
+	assert.NoError(t, a.Report(context.Background(), PanicEvent{Message: "second"}))
+	assert.NoError(t, a.Close(context.Background()))
+
+	events := flaky.Events()
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, "first", events[0].Message)
+	assert.Equal(t, uint64(0), a.Failed())
+
+	assert.ErrorIs(t, a.Report(context.Background(), PanicEvent{}), ErrReporterClosed)
+
+	flaky = &flakyReporter{failures: 3}
+	a = NewAsyncReporter(flaky, AsyncReporterConfig{
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+	})
+	assert.NoError(t, a.Report(context.Background(), PanicEvent{}))
+	assert.NoError(t, a.Close(context.Background()))
+	assert.Equal(t, 0, len(flaky.Events()))
+	assert.Equal(t, uint64(1), a.Failed())
+}
+
+func TestAsyncReporterDrops(t *testing.T) {
+	flaky := &flakyReporter{block: make(chan struct{})}
+	a := NewAsyncReporter(flaky, AsyncReporterConfig{QueueSize: 1})
+
+	// The first event may be taken by the worker, and the second one fills the queue.
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = a.Report(context.Background(), PanicEvent{})
+	}
+	assert.ErrorIs(t, err, ErrReportQueueFull)
+	assert.Equal(t, uint64(1), a.Dropped())
+
+	close(flaky.block)
+	assert.NoError(t, a.Close(context.Background()))
+}
+
+func TestFileReporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "panics.jsonl")
+	reporter, err := NewFileReporter(path)
+	assert.NoError(t, err)
+
+	log, _ := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{
+		Reporter: reporter,
+	})
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("oops")
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	assert.NoError(t, reporter.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	var event map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &event))
+	assert.Equal(t, "panic: oops", event["message"])
+	assert.Equal(t, "/panic", event["path"])
+	assert.NotEmpty(t, event["stack"])
+	assert.Equal(t, "oops", event["fields"].(map[string]interface{})["error"])
+}
+
+func TestAsyncReporterNoRetries(t *testing.T) {
+	flaky := &flakyReporter{failures: 1}
+	a := NewAsyncReporter(flaky, AsyncReporterConfig{
+		// Replaced with the default
+		QueueSize:  -1,
+		MaxRetries: NoRetries,
+		RetryDelay: time.Millisecond,
+	})
+
+	assert.NoError(t, a.Report(context.Background(), PanicEvent{}))
+	assert.NoError(t, a.Close(context.Background()))
+	assert.Equal(t, 0, len(flaky.Events()))
+	assert.Equal(t, uint64(1), a.Failed())
+}
+
+func TestAsyncReporterCloseDeadline(t *testing.T) {
+	flaky := &flakyReporter{failures: 100}
+	a := NewAsyncReporter(flaky, AsyncReporterConfig{
+		RetryDelay: time.Hour,
+	})
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, a.Report(context.Background(), PanicEvent{}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, a.Close(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The remaining events are given up.
+	assert.Eventually(t, func() bool { return a.Failed() == 3 }, time.Second, 10*time.Millisecond)
+}
+
+func TestRecoverWithReporterCallsFieldAdderOnce(t *testing.T) {
+	calls := 0
+	fields := NewFieldBuilder(LoggerConfig{
+		FieldAdder: func(c echo.Context) []zapcore.Field {
+			calls++
+			return []zapcore.Field{zap.String("hello", "world!")}
+		},
+	})
+	reporter := &MemoryReporter{}
+
+	for _, m := range []echo.MiddlewareFunc{
+		func() echo.MiddlewareFunc {
+			log, _ := createTestZapLogger()
+			return RecoverWithConfig(log, RecoverConfig{Fields: fields, Reporter: reporter})
+		}(),
+		func() echo.MiddlewareFunc {
+			log, _ := createTestZapLogger()
+			return Middleware(log, Config{
+				Logger:  LoggerConfig{FieldAdder: fields.config.FieldAdder},
+				Recover: RecoverConfig{Reporter: reporter},
+			})
+		}(),
+	} {
+		calls = 0
+		e := createTestEcho(m)
+		e.GET("/panic", func(c echo.Context) error {
+			panic("oops")
+		})
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+		assert.Equal(t, 1, calls)
+	}
+
+	events := reporter.Events()
+	assert.Equal(t, 2, len(events))
+	for _, event := range events {
+		assert.Equal(t, "world!", event.Fields["hello"])
+	}
+}
+
+type failingReporter struct{}
+
+func (failingReporter) Report(ctx context.Context, event PanicEvent) error {
+	return errors.New("unavailable")
+}
+
+func TestRecoverWithFailingReporter(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, RecoverConfig{Reporter: failingReporter{}})
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		panic("oops")
+	})
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	assert.Equal(t, 2, logs.Len())
+	l := logs.All()[1]
+	assert.Equal(t, zapcore.WarnLevel, l.Level)
+	assert.Equal(t, "Failed to report panic", l.Message)
+	assert.Equal(t, "unavailable", l.ContextMap()["error"].(string))
+	assert.Equal(t, logs.All()[0].ContextMap()["panic_fingerprint"], l.ContextMap()["panic_fingerprint"])
+}
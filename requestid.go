@@ -0,0 +1,111 @@
+package zap4echo
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RequestID returns the request ID of the current request. It is either
+// received from the client, set by the server, or generated with RequestIDGenerator.
+//
+// If no middleware of zap4echo has seen a request ID, the request and response
+// headers are looked up using DefaultRequestIDHeader.
+func RequestID(c echo.Context) string {
+	if state := getState(c); state != nil && state.requestID != "" {
+		return state.requestID
+	}
+	return getRequestID(c, "")
+}
+
+// Resolve the request ID before the handler runs. If there is no request ID
+// and a generator is given, a request ID is generated and set to the response header.
+func resolveRequestID(c echo.Context, customHeader string, generator func() string) {
+	requestID := getRequestID(c, customHeader)
+	if requestID == "" && generator != nil {
+		requestID = generator()
+		header := customHeader
+		if header == "" {
+			header = DefaultRequestIDHeader
+		}
+		c.Response().Header().Set(header, requestID)
+	}
+	if requestID != "" {
+		getOrCreateState(c).requestID = requestID
+	}
+}
+
+// GenerateUUIDv4 generates a random UUID (version 4).
+func GenerateUUIDv4() string {
+	var u [16]byte
+	randomBytes(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40 // Version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // Variant is 10
+	return formatUUID(u)
+}
+
+// GenerateUUIDv7 generates a time ordered UUID (version 7).
+func GenerateUUIDv7() string {
+	var u [16]byte
+	randomBytes(u[6:])
+	ms := uint64(time.Now().UnixMilli())
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+	u[6] = (u[6] & 0x0f) | 0x70 // Version 7
+	u[8] = (u[8] & 0x3f) | 0x80 // Variant is 10
+	return formatUUID(u)
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// GenerateULID generates a ULID.
+//
+// See: https://github.com/ulid/spec
+func GenerateULID() string {
+	var u [16]byte
+	randomBytes(u[6:])
+	ms := uint64(time.Now().UnixMilli())
+	u[0] = byte(ms >> 40)
+	u[1] = byte(ms >> 32)
+	u[2] = byte(ms >> 24)
+	u[3] = byte(ms >> 16)
+	u[4] = byte(ms >> 8)
+	u[5] = byte(ms)
+
+	hi := binary.BigEndian.Uint64(u[:8])
+	lo := binary.BigEndian.Uint64(u[8:])
+	var s [26]byte
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i] = crockfordBase32[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+func formatUUID(u [16]byte) string {
+	var s [36]byte
+	hex.Encode(s[0:8], u[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], u[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], u[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], u[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], u[10:])
+	return string(s[:])
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,95 @@
+package zap4echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDGenerator(t *testing.T) {
+	log, logs := createTestZapLogger()
+	e := createTestEcho(LoggerWithConfig(log, LoggerConfig{
+		RequestIDGenerator: GenerateUUIDv4,
+	}))
+	e.Use(RecoverWithConfig(log, RecoverConfig{
+		RequestIDGenerator: GenerateUUIDv4,
+	}))
+
+	var handlerRequestID string
+	e.GET("/panic", func(c echo.Context) error {
+		handlerRequestID = RequestID(c)
+		if true {
+			panic("oops")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	requestID := res.Header.Get(echo.HeaderXRequestID)
+	assert.NotEmpty(t, requestID)
+	assert.Equal(t, requestID, handlerRequestID)
+	assert.Equal(t, 2, logs.Len())
+	for _, l := range logs.All() {
+		assert.Equal(t, requestID, l.ContextMap()["request_id"].(string))
+	}
+}
+
+func TestRequestIDGeneratorWithExistingRequestID(t *testing.T) {
+	const requestIDHeader = "My1337RequestID"
+
+	log, logs := createTestZapLogger()
+	e := createTestEcho(LoggerWithConfig(log, LoggerConfig{
+		CustomRequestIDHeader: requestIDHeader,
+		RequestIDGenerator:    GenerateULID,
+	}))
+
+	var handlerRequestID string
+	e.GET("/", func(c echo.Context) error {
+		handlerRequestID = RequestID(c)
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(requestIDHeader, "31337")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, "", res.Header.Get(requestIDHeader))
+	assert.Equal(t, "31337", handlerRequestID)
+	assert.Equal(t, "31337", logs.All()[0].ContextMap()["request_id"].(string))
+}
+
+func TestRequestIDWithoutMiddleware(t *testing.T) {
+	e := echo.New()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(echo.HeaderXRequestID, "1337")
+	c := e.NewContext(r, httptest.NewRecorder())
+
+	assert.Equal(t, "1337", RequestID(c))
+}
+
+func TestGenerateRequestID(t *testing.T) {
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	uuidV7 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulid := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+	for i := 0; i < 100; i++ {
+		assert.Regexp(t, uuidV4, GenerateUUIDv4())
+		assert.Regexp(t, uuidV7, GenerateUUIDv7())
+		assert.Regexp(t, ulid, GenerateULID())
+	}
+
+	assert.NotEqual(t, GenerateUUIDv4(), GenerateUUIDv4())
+	assert.NotEqual(t, GenerateULID(), GenerateULID())
+}
@@ -0,0 +1,52 @@
+package zap4echo
+
+import (
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap/zapcore"
+)
+
+// Caches the names of the routes, keyed by method and path.
+type routeNames struct {
+	names sync.Map
+}
+
+func (r *routeNames) get(c echo.Context) string {
+	method, path := c.Request().Method, c.Path()
+	// No route has matched (e.g. 404).
+	if path == "" {
+		return ""
+	}
+	key := method + " " + path
+	if name, ok := r.names.Load(key); ok {
+		return name.(string)
+	}
+
+	// Routes copies all routes, so a path without a route for the method (e.g. 405)
+	// is cached too. Routes are expected to be added before serving requests.
+	name := ""
+	for _, route := range c.Echo().Routes() {
+		if route.Method == method && route.Path == path {
+			name = route.Name
+			break
+		}
+	}
+	r.names.Store(key, name)
+	return name
+}
+
+type paramsObject struct {
+	names  []string
+	values []string
+}
+
+func (p paramsObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for i, name := range p.names {
+		if i >= len(p.values) {
+			break
+		}
+		enc.AddString(name, p.values[i])
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package zap4echo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Schema decides the keys of the logged fields. The zero value
+// uses the default keys documented in README (e.g. `status`, `latency`).
+//
+// Only the fields logged by zap4echo are affected. Fields added
+// with FieldAdder and AddFields are logged as is.
+type Schema struct {
+	// Maps the default keys to custom keys. Fields whose keys
+	// are not in the map keep their default keys.
+	// If a key is mapped to an empty string, the field is not logged.
+	Keys map[string]string
+
+	// If not empty, fields whose (mapped) keys start with `Group + "."`
+	// are logged under a nested object with this key.
+	Group string
+
+	// If true, `latency` is logged as a string of seconds with
+	// an `s` suffix (e.g. `0.0123s`) instead of a duration.
+	LatencyAsString bool
+	// If true, `latency` is logged as an integer of nanoseconds instead of a
+	// duration, whose encoding depends on the encoder config of the logger.
+	LatencyAsNanos bool
+}
+
+// Elastic Common Schema. `error_internal` has no
+// counterpart in ECS, and is logged as is.
+//
+// See: https://www.elastic.co/guide/en/ecs/current/ecs-http.html
+var SchemaECS = Schema{
+	Keys: map[string]string{
+		"proto":         "http.version",
+		"host":          "url.domain",
+		"method":        "http.request.method",
+		"status":        "http.response.status_code",
+		"response_size": "http.response.body.bytes",
+		"latency":       "event.duration",
+		"client_ip":     "client.ip",
+		"user_agent":    "user_agent.original",
+		"path":          "url.original",
+		"query":         "url.query",
+		"request_id":    "http.request.id",
+		"referer":       "http.request.referrer",
+		"request_body":  "http.request.body.content",
+		"response_body": "http.response.body.content",
+		"error":         "error.message",
+		"error_code":    "error.code",
+		"stacktrace":    "error.stack_trace",
+		"trace_id":      "trace.id",
+		"span_id":       "span.id",
+	},
+	LatencyAsNanos: true,
+}
+
+// OpenTelemetry semantic conventions for HTTP. `path` includes the
+// query string, so it is logged as `url.original`.
+//
+// See: https://opentelemetry.io/docs/specs/semconv/http/http-spans/
+var SchemaOTel = Schema{
+	Keys: map[string]string{
+		"proto":         "network.protocol.version",
+		"host":          "server.address",
+		"method":        "http.request.method",
+		"status":        "http.response.status_code",
+		"response_size": "http.response.body.size",
+		"latency":       "http.server.request.duration",
+		"client_ip":     "client.address",
+		"user_agent":    "user_agent.original",
+		"path":          "url.original",
+		"query":         "url.query",
+		"route":         "http.route",
+		"referer":       "http.request.header.referer",
+		"error":         "exception.message",
+		"stacktrace":    "exception.stacktrace",
+	},
+}
+
+// Google Cloud Logging. HTTP related fields are
+// logged under the nested `httpRequest` object.
+//
+// See: https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest
+var SchemaGCP = Schema{
+	Keys: map[string]string{
+		"proto":         "httpRequest.protocol",
+		"method":        "httpRequest.requestMethod",
+		"status":        "httpRequest.status",
+		"response_size": "httpRequest.responseSize",
+		"latency":       "httpRequest.latency",
+		"client_ip":     "httpRequest.remoteIp",
+		"user_agent":    "httpRequest.userAgent",
+		"path":          "httpRequest.requestUrl",
+		"referer":       "httpRequest.referer",
+	},
+	Group:           "httpRequest",
+	LatencyAsString: true,
+}
+
+func (s *Schema) isDefault() bool {
+	return len(s.Keys) == 0 && s.Group == "" && !s.LatencyAsString && !s.LatencyAsNanos
+}
+
+// Rename the fields in place.
+func (s *Schema) apply(fields []zapcore.Field) []zapcore.Field {
+	if s.isDefault() {
+		return fields
+	}
+
+	var grouped []zapcore.Field
+	renamed := fields[:0]
+	for _, f := range fields {
+		if s.LatencyAsString && f.Key == "latency" && f.Type == zapcore.DurationType {
+			f = zap.String(f.Key, strconv.FormatFloat(time.Duration(f.Integer).Seconds(), 'f', -1, 64)+"s")
+		} else if s.LatencyAsNanos && f.Key == "latency" && f.Type == zapcore.DurationType {
+			f = zap.Int64(f.Key, f.Integer)
+		}
+		if key, ok := s.Keys[f.Key]; ok {
+			if key == "" {
+				continue
+			}
+			f.Key = key
+		}
+		if s.Group != "" && strings.HasPrefix(f.Key, s.Group+".") {
+			f.Key = f.Key[len(s.Group)+1:]
+			grouped = append(grouped, f)
+			continue
+		}
+		renamed = append(renamed, f)
+	}
+	if len(grouped) > 0 {
+		renamed = append(renamed, zap.Dict(s.Group, grouped...))
+	}
+	return renamed
+}
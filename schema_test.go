@@ -0,0 +1,164 @@
+package zap4echo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLoggerWithSchemaECS(t *testing.T) {
+	config := LoggerConfig{
+		Schema: SchemaECS,
+		FieldAdder: func(c echo.Context) []zapcore.Field {
+			return []zapcore.Field{zap.String("status", "untouched")}
+		},
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/hello", func(c echo.Context) error {
+		return c.String(http.StatusOK, "Hello!")
+	})
+
+	r := httptest.NewRequest("GET", "/hello", nil)
+	r.Header.Set(echo.HeaderXRequestID, "1337")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	l := logs.All()[0]
+	assert.Equal(t, "GET", l.ContextMap()["http.request.method"].(string))
+	assert.Equal(t, int64(http.StatusOK), l.ContextMap()["http.response.status_code"].(int64))
+	assert.Equal(t, "/hello", l.ContextMap()["url.original"].(string))
+	assert.Equal(t, "192.0.2.1", l.ContextMap()["client.ip"].(string))
+	assert.Equal(t, "1337", l.ContextMap()["http.request.id"].(string))
+	assert.IsType(t, int64(0), l.ContextMap()["event.duration"])
+	assert.Nil(t, l.ContextMap()["method"])
+	assert.Equal(t, "untouched", l.ContextMap()["status"].(string))
+}
+
+func TestLoggerWithSchemaECSErrorFields(t *testing.T) {
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, LoggerConfig{Schema: SchemaECS})
+	e := createTestEcho(m)
+
+	e.GET("/error", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadGateway, "upstream failed").SetInternal(errors.New("connection refused"))
+	})
+
+	r := httptest.NewRequest("GET", "/error", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, "upstream failed", l.ContextMap()["error.message"].(string))
+	assert.Equal(t, int64(http.StatusBadGateway), l.ContextMap()["error.code"].(int64))
+	// Not in ECS
+	assert.Equal(t, "connection refused", l.ContextMap()["error_internal"].(string))
+}
+
+func TestLoggerWithSchemaGCP(t *testing.T) {
+	config := LoggerConfig{
+		Schema: SchemaGCP,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/hello", func(c echo.Context) error {
+		FromContext(c).Info("Handler")
+		return c.String(http.StatusOK, "Hello!")
+	})
+
+	r := httptest.NewRequest("GET", "/hello", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	res := w.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	l := logs.All()[1]
+	httpRequest := l.ContextMap()["httpRequest"].(map[string]interface{})
+	assert.Equal(t, "GET", httpRequest["requestMethod"])
+	assert.Equal(t, "/hello", httpRequest["requestUrl"])
+	assert.Equal(t, int64(http.StatusOK), httpRequest["status"])
+	assert.Equal(t, "192.0.2.1", httpRequest["remoteIp"])
+	assert.Equal(t, "HTTP/1.1", httpRequest["protocol"])
+	assert.True(t, strings.HasSuffix(httpRequest["latency"].(string), "s"))
+	assert.Equal(t, "example.com", l.ContextMap()["host"].(string))
+
+	// Logger of the handler
+	l = logs.All()[0]
+	httpRequest = l.ContextMap()["httpRequest"].(map[string]interface{})
+	assert.Equal(t, "GET", httpRequest["requestMethod"])
+	assert.Equal(t, "/hello", httpRequest["requestUrl"])
+}
+
+func TestLoggerWithCustomSchema(t *testing.T) {
+	config := LoggerConfig{
+		Schema: Schema{
+			Keys: map[string]string{
+				"status":      "code",
+				"status_text": "",
+			},
+		},
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, int64(http.StatusOK), l.ContextMap()["code"].(int64))
+	assert.Nil(t, l.ContextMap()["status"])
+	assert.Nil(t, l.ContextMap()["status_text"])
+	assert.Equal(t, "GET", l.ContextMap()["method"].(string))
+}
+
+func TestRecoverWithSchemaOTel(t *testing.T) {
+	config := RecoverConfig{
+		Schema: SchemaOTel,
+	}
+
+	log, logs := createTestZapLogger()
+	m := RecoverWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/panic", func(c echo.Context) error {
+		if true {
+			panic("oops")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic?page=1", nil)
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, "oops", l.ContextMap()["exception.message"].(string))
+	assert.Equal(t, "GET", l.ContextMap()["http.request.method"].(string))
+	assert.Equal(t, "/panic?page=1", l.ContextMap()["url.original"].(string))
+	assert.Nil(t, l.ContextMap()["url.path"])
+	assert.Equal(t, "192.0.2.1", l.ContextMap()["client.address"].(string))
+}
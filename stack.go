@@ -0,0 +1,168 @@
+package zap4echo
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// StackFrame is a frame of a stack trace.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func (f StackFrame) String() string {
+	return f.Function + " (" + f.File + ":" + strconv.Itoa(f.Line) + ")"
+}
+
+func (f StackFrame) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("function", f.Function)
+	enc.AddString("file", f.File)
+	enc.AddInt("line", f.Line)
+	return nil
+}
+
+func (f StackFrame) isRuntime() bool {
+	return strings.HasPrefix(f.Function, "runtime.")
+}
+
+func (f StackFrame) isInternal() bool {
+	// Tests of zap4echo are not internal.
+	if strings.HasSuffix(f.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(f.Function, "github.com/karagenc/zap4echo.") ||
+		strings.HasPrefix(f.Function, "github.com/labstack/echo/v4.") ||
+		strings.HasPrefix(f.Function, "github.com/labstack/echo/v4/")
+}
+
+// Whether the frame belongs to the standard library. As in the go command,
+// packages whose path has no dot in its first element are considered to be
+// of the standard library, except main.
+func (f StackFrame) isStdlib() bool {
+	if i := strings.IndexByte(f.Function, '/'); i >= 0 {
+		return !strings.Contains(f.Function[:i], ".")
+	}
+	pkg := f.Function
+	if i := strings.IndexByte(pkg, '.'); i >= 0 {
+		pkg = pkg[:i]
+	}
+	return pkg != "" && pkg != "main"
+}
+
+// Whether the frame belongs to the application, and not to the
+// Go runtime, the standard library, zap4echo or Echo.
+func (f StackFrame) isApplication() bool {
+	return !f.isRuntime() && !f.isStdlib() && !f.isInternal()
+}
+
+type stackFrames []StackFrame
+
+func (s stackFrames) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, f := range s {
+		if err := enc.AppendObject(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Capture the stack of the current goroutine.
+// skip is the number of frames to skip, with 0 identifying the caller of captureStack.
+func captureStack(skip int) []StackFrame {
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(skip+2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, len(pcs)*2)
+	}
+
+	frames := make([]StackFrame, 0, len(pcs))
+	iter := runtime.CallersFrames(pcs)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, StackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+		if !more {
+			break
+		}
+	}
+	return frames
+}
+
+type stackFilter struct {
+	omitRuntime  bool
+	omitInternal bool
+	depth        int
+}
+
+func (f *stackFilter) filter(frames []StackFrame) []StackFrame {
+	filtered := make([]StackFrame, 0, len(frames))
+	for _, frame := range frames {
+		if f.omitRuntime && frame.isRuntime() {
+			continue
+		}
+		if f.omitInternal && frame.isInternal() {
+			continue
+		}
+		filtered = append(filtered, frame)
+		if f.depth > 0 && len(filtered) == f.depth {
+			break
+		}
+	}
+	return filtered
+}
+
+// Returns the first frame that belongs to the application.
+func panicLocation(frames []StackFrame) (StackFrame, bool) {
+	for _, frame := range frames {
+		if frame.isApplication() {
+			return frame, true
+		}
+	}
+	return StackFrame{}, false
+}
+
+// Capture the stack trace with runtime.Stack. If grow is true, the buffer is
+// doubled until the stack trace fits, or until it reaches max.
+func captureRawStack(size, max int, all, grow bool) (stack []byte, truncated bool) {
+	for {
+		stack = make([]byte, size)
+		n := runtime.Stack(stack, all)
+		// If n is smaller than the buffer, the stack trace fits.
+		if n < size {
+			return stack[:n], false
+		}
+		if !grow || size >= max {
+			return stack[:n], true
+		}
+		size *= 2
+		if size > max {
+			size = max
+		}
+	}
+}
+
+// Write the stack trace to a new file in dir, and return the path of the file.
+func dumpStack(dir string, stack []byte) (string, error) {
+	f, err := os.CreateTemp(dir, "zap4echo-stacktrace-*.txt")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(stack)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return f.Name(), err
+}
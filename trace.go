@@ -0,0 +1,183 @@
+package zap4echo
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TraceFormat specifies the trace context headers to be parsed.
+// Formats can be combined, e.g. `TraceW3C | TraceB3`.
+// If multiple formats are present in a request, W3C takes precedence.
+type TraceFormat int
+
+const (
+	// W3C Trace Context (`traceparent` and `tracestate` headers)
+	//
+	// See: https://www.w3.org/TR/trace-context/
+	TraceW3C TraceFormat = 1 << iota
+
+	// B3 single header (`b3`) and multi headers (`X-B3-TraceId`, `X-B3-SpanId`, `X-B3-Sampled` and `X-B3-Flags`)
+	//
+	// See: https://github.com/openzipkin/b3-propagation
+	TraceB3
+)
+
+const (
+	headerTraceparent = "Traceparent"
+	headerTracestate  = "Tracestate"
+	headerB3          = "B3"
+	headerB3TraceID   = "X-B3-Traceid"
+	headerB3SpanID    = "X-B3-Spanid"
+	headerB3Sampled   = "X-B3-Sampled"
+	headerB3Flags     = "X-B3-Flags"
+)
+
+type traceContext struct {
+	traceID string
+	spanID  string
+	sampled bool
+	state   string
+
+	// True if the trace ID was started by zap4echo. Sampling decision is unknown.
+	generated bool
+}
+
+func (t *traceContext) fields() []zapcore.Field {
+	if t == nil {
+		return nil
+	}
+	fields := make([]zapcore.Field, 0, 4)
+	fields = append(fields, zap.String("trace_id", t.traceID))
+	if t.spanID != "" {
+		fields = append(fields, zap.String("span_id", t.spanID))
+	}
+	if !t.generated {
+		fields = append(fields, zap.Bool("trace_sampled", t.sampled))
+	}
+	if t.state != "" {
+		fields = append(fields, zap.String("trace_state", t.state))
+	}
+	return fields
+}
+
+// Resolve the trace context before the handler runs. If the trace context was
+// already resolved by another middleware of zap4echo, it is reused.
+func resolveTrace(c echo.Context, format TraceFormat, generate bool) *traceContext {
+	if format == 0 && !generate {
+		return nil
+	}
+
+	state := getOrCreateState(c)
+	if state.trace != nil {
+		return state.trace
+	}
+
+	h := c.Request().Header
+	var t *traceContext
+	if format&TraceW3C != 0 {
+		t = parseTraceparent(h.Get(headerTraceparent))
+		if t != nil {
+			t.state = h.Get(headerTracestate)
+		}
+	}
+	if t == nil && format&TraceB3 != 0 {
+		t = parseB3Single(h.Get(headerB3))
+		if t == nil {
+			t = parseB3Multi(h.Get(headerB3TraceID), h.Get(headerB3SpanID), h.Get(headerB3Sampled), h.Get(headerB3Flags))
+		}
+	}
+	if t == nil && generate {
+		t = &traceContext{
+			traceID:   randomHex(16),
+			spanID:    randomHex(8),
+			generated: true,
+		}
+	}
+
+	state.trace = t
+	return t
+}
+
+// Format: {version}-{trace-id}-{parent-id}-{trace-flags}
+func parseTraceparent(s string) *traceContext {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, "-")
+	if len(parts) < 4 {
+		return nil
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" ||
+		// Version 00 has exactly 4 parts. Future versions may append more.
+		(version == "00" && len(parts) != 4) ||
+		!isHex(traceID, 32) || isZero(traceID) ||
+		!isHex(spanID, 16) || isZero(spanID) ||
+		!isHex(flags, 2) {
+		return nil
+	}
+	f, _ := hex.DecodeString(flags)
+	return &traceContext{
+		traceID: traceID,
+		spanID:  spanID,
+		sampled: f[0]&1 == 1,
+	}
+}
+
+// Format: {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+// The last two are optional.
+func parseB3Single(s string) *traceContext {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 2 {
+		// Empty, or only contains the sampling state.
+		return nil
+	}
+	sampled := ""
+	if len(parts) > 2 {
+		sampled = parts[2]
+	}
+	return parseB3Multi(parts[0], parts[1], sampled, "")
+}
+
+func parseB3Multi(traceID, spanID, sampled, flags string) *traceContext {
+	traceID = strings.ToLower(traceID)
+	spanID = strings.ToLower(spanID)
+	if !(isHex(traceID, 16) || isHex(traceID, 32)) || isZero(traceID) ||
+		!isHex(spanID, 16) || isZero(spanID) {
+		return nil
+	}
+	// 64-bit trace IDs are padded to be compatible with W3C.
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	return &traceContext{
+		traceID: traceID,
+		spanID:  spanID,
+		sampled: sampled == "1" || sampled == "d" || sampled == "true" || flags == "1",
+	}
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	randomBytes(b)
+	return hex.EncodeToString(b)
+}
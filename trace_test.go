@@ -0,0 +1,124 @@
+package zap4echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggerWithTraceW3C(t *testing.T) {
+	config := LoggerConfig{
+		TraceFormat: TraceW3C | TraceB3,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/", func(c echo.Context) error {
+		FromContext(c).Info("Handler")
+		return c.NoContent(http.StatusOK)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("tracestate", "congo=t61rcWkgMzE")
+	r.Header.Set("b3", "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-0")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	for _, l := range logs.All() {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", l.ContextMap()["trace_id"].(string))
+		assert.Equal(t, "00f067aa0ba902b7", l.ContextMap()["span_id"].(string))
+		assert.Equal(t, true, l.ContextMap()["trace_sampled"].(bool))
+		assert.Equal(t, "congo=t61rcWkgMzE", l.ContextMap()["trace_state"].(string))
+	}
+}
+
+func TestLoggerWithTraceB3(t *testing.T) {
+	config := LoggerConfig{
+		TraceFormat: TraceB3,
+	}
+
+	log, logs := createTestZapLogger()
+	m := LoggerWithConfig(log, config)
+	e := createTestEcho(m)
+
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Single header
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set("b3", "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-0")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l := logs.All()[0]
+	assert.Equal(t, "80f198ee56343ba864fe8b2a57d3eff7", l.ContextMap()["trace_id"].(string))
+	assert.Equal(t, "e457b5a2e4d86bd1", l.ContextMap()["span_id"].(string))
+	assert.Equal(t, false, l.ContextMap()["trace_sampled"].(bool))
+
+	// Multi headers
+	r = httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-B3-TraceId", "a3ce929d0e0e4736")
+	r.Header.Set("X-B3-SpanId", "00f067aa0ba902b7")
+	r.Header.Set("X-B3-Sampled", "1")
+	w = httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	l = logs.All()[1]
+	assert.Equal(t, "0000000000000000a3ce929d0e0e4736", l.ContextMap()["trace_id"].(string))
+	assert.Equal(t, "00f067aa0ba902b7", l.ContextMap()["span_id"].(string))
+	assert.Equal(t, true, l.ContextMap()["trace_sampled"].(bool))
+}
+
+func TestGenerateTraceID(t *testing.T) {
+	log, logs := createTestZapLogger()
+	e := createTestEcho(LoggerWithConfig(log, LoggerConfig{
+		TraceFormat:     TraceW3C,
+		GenerateTraceID: true,
+	}))
+	e.Use(RecoverWithConfig(log, RecoverConfig{
+		TraceFormat:     TraceW3C,
+		GenerateTraceID: true,
+	}))
+
+	e.GET("/panic", func(c echo.Context) error {
+		if true {
+			panic("oops")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest("GET", "/panic", nil)
+	// Invalid, all zeros.
+	r.Header.Set("traceparent", "00-00000000000000000000000000000000-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, r)
+
+	assert.Equal(t, 2, logs.Len())
+	recovered, served := logs.All()[0], logs.All()[1]
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), served.ContextMap()["trace_id"].(string))
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{16}$`), served.ContextMap()["span_id"].(string))
+	assert.Nil(t, served.ContextMap()["trace_sampled"])
+	assert.Equal(t, served.ContextMap()["trace_id"], recovered.ContextMap()["trace_id"])
+}
+
+func TestParseTraceparent(t *testing.T) {
+	assert.NotNil(t, parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"))
+	// Future versions may have more parts.
+	assert.NotNil(t, parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"))
+
+	assert.Nil(t, parseTraceparent(""))
+	assert.Nil(t, parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra"))
+	assert.Nil(t, parseTraceparent("ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"))
+	assert.Nil(t, parseTraceparent("00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-00"))
+	assert.Nil(t, parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-00"))
+	assert.Nil(t, parseTraceparent("00-4bf92f3577b34da6-00f067aa0ba902b7-00"))
+}